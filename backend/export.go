@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/export"
+	"mini-amm-bot/internal/models"
+	util "mini-amm-bot/internal/util"
+)
+
+const exportUsage = "用法: mini-amm-bot export <bot-actions | pool-snapshots> [-format csv|ndjson] [-from T] [-to T] [-pool NAME] [-type COMPOUND|REBALANCE] [-status success|failed] [-o FILE]"
+
+// runExport 处理 export 子命令，与 /api/export 接口输出相同的内容，默认写到标准输出
+func runExport(args []string) {
+	if len(args) == 0 || (args[0] != "bot-actions" && args[0] != "pool-snapshots") {
+		log.Fatal(exportUsage)
+	}
+	target := args[0]
+
+	flags := flag.NewFlagSet("export "+target, flag.ExitOnError)
+	formatFlag := flags.String("format", "csv", "导出格式: csv 或 ndjson")
+	fromFlag := flags.String("from", "", "开始时间（包含），RFC3339 或 unix 秒")
+	toFlag := flags.String("to", "", "结束时间（不包含），RFC3339 或 unix 秒")
+	poolFlag := flags.String("pool", "", "只导出该池")
+	typeFlag := flags.String("type", "", "只导出该类型的操作记录: COMPOUND 或 REBALANCE")
+	statusFlag := flags.String("status", "", "只导出该状态的操作记录: success 或 failed")
+	outFlag := flags.String("o", "", "输出文件，默认标准输出")
+	flags.Parse(args[1:])
+	if flags.NArg() > 0 {
+		log.Fatal(exportUsage)
+	}
+
+	format, err := export.ParseFormat(*formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	from := parseExportTime("from", *fromFlag)
+	to := parseExportTime("to", *toFlag)
+	var pool *string
+	if *poolFlag != "" {
+		pool = poolFlag
+	}
+
+	config, err := util.LoadDatabaseConfig()
+	if err != nil {
+		log.Fatalf("加载数据库配置失败: %v", err)
+	}
+	if config.Driver == db.DriverMemory {
+		log.Fatal("DB_DRIVER=memory 没有可导出的数据")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// 导出只读数据库，不执行迁移，避免抢占迁移锁或修改正在运行的 bot 的 schema
+	stores, err := db.OpenExisting(ctx, config)
+	if err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer stores.Database.Close()
+
+	out := os.Stdout
+	if *outFlag != "" {
+		out, err = os.Create(*outFlag)
+		if err != nil {
+			log.Fatalf("创建输出文件失败: %v", err)
+		}
+		defer out.Close()
+	}
+	writer := bufio.NewWriter(out)
+
+	var rows int
+	switch target {
+	case "bot-actions":
+		filter := db.QueryFilter{Pool: pool, From: from, To: to}
+		if *typeFlag != "" {
+			actionType := models.ActionType(*typeFlag)
+			if actionType != models.ActionTypeCompound && actionType != models.ActionTypeRebalance {
+				log.Fatalf("未知的操作类型: %s", *typeFlag)
+			}
+			filter.ActionType = &actionType
+		}
+		if *statusFlag != "" {
+			if *statusFlag != "success" && *statusFlag != "failed" {
+				log.Fatalf("未知的状态: %s", *statusFlag)
+			}
+			filter.Status = statusFlag
+		}
+		rows, err = export.BotActions(ctx, stores.BotActions, filter, format, writer)
+	case "pool-snapshots":
+		rows, err = export.PoolSnapshots(ctx, stores.PoolSnapshots, db.SnapshotFilter{Pool: pool, From: from, To: to}, format, writer)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		log.Fatalf("导出失败 (已写出 %d 行): %v", rows, err)
+	}
+	log.Infof("✅ 导出 %d 行", rows)
+}
+
+func parseExportTime(name, raw string) *time.Time {
+	if raw == "" {
+		return nil
+	}
+	t, err := export.ParseTime(raw)
+	if err != nil {
+		log.Fatalf("-%s 格式错误，应为 RFC3339 或 unix 秒: %s", name, raw)
+	}
+	return &t
+}
@@ -0,0 +1,77 @@
+// Package alerting 根据事件总线上的事件和定期的余额检查评估告警规则，
+// 按告警去重并限制通知频率后，异步发送到 webhook、Slack 和邮件
+package alerting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rule 告警规则
+type Rule string
+
+const (
+	RuleFailedRebalances Rule = "failed_rebalances" // 连续多次再平衡失败
+	RuleLowBalance       Rule = "low_balance"       // 签名账户 ETH 余额过低
+	RuleOracleStale      Rule = "oracle_stale"      // 市场价格过期
+	RuleRPCFailover      Rule = "rpc_failover"      // RPC 节点切换
+	RuleDeviation        Rule = "deviation"         // 偏差持续超过阈值
+)
+
+// Status 告警状态
+type Status string
+
+const (
+	StatusFiring   Status = "firing"
+	StatusResolved Status = "resolved"
+)
+
+// Severity 告警级别
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Alert 一条告警通知，通用 webhook 直接收到该结构的 JSON
+type Alert struct {
+	Rule     Rule      `json:"rule"`
+	Status   Status    `json:"status"`
+	Severity Severity  `json:"severity"`
+	Chain    string    `json:"chain,omitempty"`
+	Pool     string    `json:"pool,omitempty"` // 链级告警（余额、RPC 节点）为空
+	Summary  string    `json:"summary"`
+	StartsAt time.Time `json:"startsAt"` // 告警开始的时间，恢复通知中保持不变
+	Time     time.Time `json:"time"`     // 本次通知的时间
+}
+
+// key 去重键：同一规则、链和池视为同一条告警
+func (a Alert) key() string {
+	return string(a.Rule) + "/" + a.Chain + "/" + a.Pool
+}
+
+// Title 邮件标题，也是 Slack 消息的第一行
+func (a Alert) Title() string {
+	scope := a.Chain
+	if a.Pool != "" {
+		scope += "/" + a.Pool
+	}
+	label := "告警"
+	if a.Status == StatusResolved {
+		label = "恢复"
+	}
+	return fmt.Sprintf("[%s][%s] %s %s", label, strings.ToUpper(string(a.Severity)), a.Rule, scope)
+}
+
+// Text 纯文本正文，用于 Slack 和邮件
+func (a Alert) Text() string {
+	lines := []string{a.Title(), a.Summary}
+	if a.Status == StatusResolved {
+		lines = append(lines, fmt.Sprintf("持续时间: %s", a.Time.Sub(a.StartsAt).Round(time.Second)))
+	} else {
+		lines = append(lines, "开始时间: "+a.StartsAt.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(lines, "\n")
+}
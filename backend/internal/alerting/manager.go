@@ -0,0 +1,361 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/models"
+	util "mini-amm-bot/internal/util"
+)
+
+const (
+	// queueSize 待发送通知的缓冲，渠道长时间不可用导致队列满时丢弃新的通知
+	queueSize   = 64
+	sendTimeout = 15 * time.Second
+)
+
+// BalanceReader 读取签名账户余额，由 TransactionService 实现
+type BalanceReader interface {
+	GetBalance() (*big.Int, error)
+	GetFromAddress() common.Address
+}
+
+type balanceWatch struct {
+	chain  *util.ChainConfig
+	reader BalanceReader
+}
+
+// alertState 一条告警的去重状态
+type alertState struct {
+	last         Alert // 最近一次触发时的告警，恢复通知沿用其级别和开始时间
+	firing       bool
+	notified     bool      // 本次触发已发送过通知，恢复时才发送恢复通知
+	lastNotified time.Time // 最近一次发送触发通知的时间，用于冷却
+}
+
+// poolState 按池累计的规则状态
+type poolState struct {
+	failedRebalances int
+	reverted         bool      // 当前这次再平衡检查中有交易 revert
+	deviationSince   time.Time // 偏差开始超过阈值的时间，未超过时为零值
+}
+
+// Manager 评估告警规则并发送通知。
+// 同一告警（规则 + 链 + 池）持续触发时只在冷却时间（ALERT_COOLDOWN）过后重复通知，
+// 恢复时发送一次恢复通知；通知在 Run 的 goroutine 中发送，不阻塞事件发布方
+type Manager struct {
+	config   *util.Config
+	sinks    []Sink
+	queue    chan Alert
+	balances []balanceWatch
+	logger   *log.Entry
+
+	mu     sync.Mutex
+	alerts map[string]*alertState
+	pools  map[string]*poolState
+}
+
+func NewManager(config *util.Config, sinks []Sink) *Manager {
+	return &Manager{
+		config: config,
+		sinks:  sinks,
+		queue:  make(chan Alert, queueSize),
+		logger: logging.Component("alerts"),
+		alerts: make(map[string]*alertState),
+		pools:  make(map[string]*poolState),
+	}
+}
+
+// Enabled 是否配置了通知渠道
+func (m *Manager) Enabled() bool {
+	return len(m.sinks) > 0
+}
+
+// Sinks 已配置的通知渠道名称
+func (m *Manager) Sinks() []string {
+	names := make([]string, len(m.sinks))
+	for i, sink := range m.sinks {
+		names[i] = sink.Name()
+	}
+	return names
+}
+
+// WatchBalance 在 Run 中按 ALERT_BALANCE_CHECK_INTERVAL 检查该链签名账户余额，需在 Run 之前调用
+func (m *Manager) WatchBalance(chain *util.ChainConfig, reader BalanceReader) {
+	m.balances = append(m.balances, balanceWatch{chain: chain, reader: reader})
+}
+
+// Run 发送排队的通知并定期检查余额，ctx 取消后返回，未发送的通知被丢弃
+func (m *Manager) Run(ctx context.Context) {
+	go m.checkBalances(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-m.queue:
+			m.send(ctx, alert)
+		}
+	}
+}
+
+func (m *Manager) send(ctx context.Context, alert Alert) {
+	for _, sink := range m.sinks {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err := sink.Send(sendCtx, alert)
+		cancel()
+
+		result := "sent"
+		if err != nil {
+			result = "failed"
+			m.logger.Errorf("发送告警到 %s 失败 (%s): %v", sink.Name(), alert.Title(), err)
+		} else {
+			m.logger.Debugf("告警已发送到 %s: %s", sink.Name(), alert.Title())
+		}
+		metrics.AlertNotifications.WithLabelValues(string(alert.Rule), sink.Name(), string(alert.Status), result).Inc()
+	}
+}
+
+// Handle 注册到事件总线的处理器
+func (m *Manager) Handle(ctx context.Context, event events.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch data := event.Data.(type) {
+	case events.TickStarted:
+		if data.Action == models.ActionTypeRebalance {
+			m.poolState(event.Pool).reverted = false
+		}
+	case events.TxConfirmed:
+		if data.Action == models.ActionTypeRebalance && data.Reverted {
+			m.poolState(event.Pool).reverted = true
+		}
+	case events.TickFinished:
+		if data.Action == models.ActionTypeRebalance {
+			m.rebalanceFinishedLocked(event, data)
+		}
+	case events.OracleStale:
+		m.oracleStaleLocked(event, data)
+	case events.PoolStateUpdated:
+		// 再平衡检查只有在取得市场价格后才会发布池状态
+		m.resolveLocked(RuleOracleStale, event.Chain, event.Pool, "市场价格已恢复更新")
+		m.deviationLocked(event, data)
+	case events.RPCFailover:
+		m.rpcFailoverLocked(event, data)
+	}
+}
+
+func (m *Manager) poolState(pool string) *poolState {
+	state, ok := m.pools[pool]
+	if !ok {
+		state = &poolState{}
+		m.pools[pool] = state
+	}
+	return state
+}
+
+// rebalanceFinishedLocked 出错或有交易 revert 的检查计为一次失败，正常结束的检查（包括无需再平衡）清零
+func (m *Manager) rebalanceFinishedLocked(event events.Event, data events.TickFinished) {
+	pool := m.config.GetPool(event.Pool)
+	if pool == nil || pool.AlertFailedRebalances <= 0 {
+		return
+	}
+	state := m.poolState(event.Pool)
+	if data.Error == "" && !state.reverted {
+		state.failedRebalances = 0
+		m.resolveLocked(RuleFailedRebalances, event.Chain, event.Pool, "再平衡检查已恢复正常")
+		return
+	}
+
+	state.failedRebalances++
+	if state.failedRebalances < pool.AlertFailedRebalances {
+		return
+	}
+	reason := data.Error
+	if reason == "" {
+		reason = "交易执行失败 (revert)"
+	}
+	m.fireLocked(Alert{
+		Rule:     RuleFailedRebalances,
+		Severity: SeverityCritical,
+		Chain:    event.Chain,
+		Pool:     event.Pool,
+		Summary:  fmt.Sprintf("再平衡已连续失败 %d 次，最近一次: %s", state.failedRebalances, reason),
+	})
+}
+
+func (m *Manager) oracleStaleLocked(event events.Event, data events.OracleStale) {
+	if !m.config.Alert.OracleStale {
+		return
+	}
+	summary := fmt.Sprintf("启动后未能获取市场价格: %s", data.Error)
+	if data.LastUpdated != nil {
+		summary = fmt.Sprintf("市场价格已 %s 未更新（上限 %ds）: %s",
+			time.Since(*data.LastUpdated).Round(time.Second), int64(data.MaxAgeSeconds), data.Error)
+	}
+	m.fireLocked(Alert{
+		Rule:     RuleOracleStale,
+		Severity: SeverityCritical,
+		Chain:    event.Chain,
+		Pool:     event.Pool,
+		Summary:  summary,
+	})
+}
+
+// deviationLocked 偏差连续超过 AlertDeviationThreshold 达到 AlertDeviationDuration 后告警
+func (m *Manager) deviationLocked(event events.Event, data events.PoolStateUpdated) {
+	pool := m.config.GetPool(event.Pool)
+	if pool == nil || pool.AlertDeviationThreshold <= 0 {
+		return
+	}
+	state := m.poolState(event.Pool)
+	if data.Deviation <= pool.AlertDeviationThreshold {
+		state.deviationSince = time.Time{}
+		m.resolveLocked(RuleDeviation, event.Chain, event.Pool, fmt.Sprintf("偏差已回落到 %.2f%%", data.Deviation*100))
+		return
+	}
+
+	if state.deviationSince.IsZero() {
+		state.deviationSince = event.Time
+	}
+	duration := event.Time.Sub(state.deviationSince)
+	if duration < pool.AlertDeviationDuration {
+		return
+	}
+	m.fireLocked(Alert{
+		Rule:     RuleDeviation,
+		Severity: SeverityWarning,
+		Chain:    event.Chain,
+		Pool:     event.Pool,
+		Summary: fmt.Sprintf("偏差 %.2f%% 超过 %.2f%% 已持续 %s",
+			data.Deviation*100, pool.AlertDeviationThreshold*100, duration.Round(time.Second)),
+	})
+}
+
+// rpcFailoverLocked 节点切换是一次性事件，没有恢复通知；冷却时间内的再次切换不重复通知
+func (m *Manager) rpcFailoverLocked(event events.Event, data events.RPCFailover) {
+	if !m.config.Alert.RPCFailover {
+		return
+	}
+	alert := Alert{
+		Rule:     RuleRPCFailover,
+		Severity: SeverityWarning,
+		Chain:    event.Chain,
+		Summary:  fmt.Sprintf("RPC 节点 %s 不可用，已切换到 %s: %s", data.From, data.To, data.Reason),
+	}
+	m.fireLocked(alert)
+	state := m.alerts[alert.key()]
+	state.firing, state.notified = false, false
+}
+
+func (m *Manager) checkBalances(ctx context.Context) {
+	if len(m.balances) == 0 {
+		return
+	}
+	ticker := time.NewTicker(m.config.Alert.BalanceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, watch := range m.balances {
+			m.checkBalance(watch)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) checkBalance(watch balanceWatch) {
+	threshold := watch.chain.AlertMinBalance
+	if threshold == nil || threshold.Sign() == 0 {
+		return
+	}
+	balance, err := watch.reader.GetBalance()
+	if err != nil {
+		m.logger.Warnf("检查签名账户余额失败 [%s]: %v", watch.chain.Name, err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	address := watch.reader.GetFromAddress().Hex()
+	if balance.Cmp(threshold) >= 0 {
+		m.resolveLocked(RuleLowBalance, watch.chain.Name, "",
+			fmt.Sprintf("签名账户 %s 余额已恢复到 %.6f ETH", address, metrics.WeiToUnit(balance, 18)))
+		return
+	}
+	m.fireLocked(Alert{
+		Rule:     RuleLowBalance,
+		Severity: SeverityCritical,
+		Chain:    watch.chain.Name,
+		Summary: fmt.Sprintf("签名账户 %s 余额 %.6f ETH 低于告警阈值 %.6f ETH",
+			address, metrics.WeiToUnit(balance, 18), metrics.WeiToUnit(threshold, 18)),
+	})
+}
+
+// fireLocked 告警触发；已经在冷却时间内通知过的告警只更新状态，不重复通知。调用方需持有 mu
+func (m *Manager) fireLocked(alert Alert) {
+	now := time.Now()
+	state, ok := m.alerts[alert.key()]
+	if !ok {
+		state = &alertState{}
+		m.alerts[alert.key()] = state
+	}
+	if !state.firing {
+		state.firing = true
+		alert.StartsAt = now
+		m.logger.Warnf("🚨 %s: %s", alert.Title(), alert.Summary)
+	} else {
+		alert.StartsAt = state.last.StartsAt
+	}
+	alert.Status = StatusFiring
+	alert.Time = now
+	state.last = alert
+
+	if !state.lastNotified.IsZero() && now.Sub(state.lastNotified) < m.config.Alert.Cooldown {
+		metrics.AlertsSuppressed.WithLabelValues(string(alert.Rule)).Inc()
+		return
+	}
+	state.notified = true
+	state.lastNotified = now
+	m.enqueue(alert)
+}
+
+// resolveLocked 告警恢复，只有发送过触发通知的告警才发送恢复通知。调用方需持有 mu
+func (m *Manager) resolveLocked(rule Rule, chain, pool, summary string) {
+	state, ok := m.alerts[Alert{Rule: rule, Chain: chain, Pool: pool}.key()]
+	if !ok || !state.firing {
+		return
+	}
+	state.firing = false
+
+	alert := state.last
+	alert.Status = StatusResolved
+	alert.Summary = summary
+	alert.Time = time.Now()
+	m.logger.Infof("✅ %s: %s", alert.Title(), summary)
+	if !state.notified {
+		return
+	}
+	state.notified = false
+	m.enqueue(alert)
+}
+
+func (m *Manager) enqueue(alert Alert) {
+	select {
+	case m.queue <- alert:
+	default:
+		m.logger.Errorf("告警发送队列已满，丢弃通知: %s", alert.Title())
+	}
+}
@@ -0,0 +1,327 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/models"
+	util "mini-amm-bot/internal/util"
+)
+
+// fakeSink 记录收到的告警，err 非空时发送失败
+type fakeSink struct {
+	mu     sync.Mutex
+	alerts []Alert
+	err    error
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Send(ctx context.Context, alert Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alert)
+	return s.err
+}
+
+// take 返回并清空已收到的告警
+func (s *fakeSink) take() []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alerts := s.alerts
+	s.alerts = nil
+	return alerts
+}
+
+type testManager struct {
+	*Manager
+	sink *fakeSink
+}
+
+func newTestManager(cooldown time.Duration) *testManager {
+	config := &util.Config{
+		Alert:  util.AlertConfig{Cooldown: cooldown, OracleStale: true, RPCFailover: true},
+		Chains: []*util.ChainConfig{{Name: "local"}},
+		Pools: []*util.PoolConfig{{
+			Name:                    "main",
+			Chain:                   "local",
+			AlertFailedRebalances:   3,
+			AlertDeviationThreshold: 0.05,
+			AlertDeviationDuration:  10 * time.Minute,
+		}},
+	}
+	sink := &fakeSink{}
+	return &testManager{Manager: NewManager(config, []Sink{sink}), sink: sink}
+}
+
+// handle 把 data 作为池 main 的事件交给 Handle，并同步发送排队的通知
+func (m *testManager) handle(at time.Time, data events.Payload) []Alert {
+	m.Handle(context.Background(), events.Event{Time: at, Chain: "local", Pool: "main", Data: data})
+	return m.flush()
+}
+
+func (m *testManager) flush() []Alert {
+	for len(m.queue) > 0 {
+		m.send(context.Background(), <-m.queue)
+	}
+	return m.sink.take()
+}
+
+// rebalanceTick 一次完整的再平衡检查，errMsg 非空表示检查出错，reverted 表示交易 revert
+func (m *testManager) rebalanceTick(errMsg string, reverted bool) []Alert {
+	now := time.Now()
+	m.Handle(context.Background(), events.Event{Time: now, Chain: "local", Pool: "main",
+		Data: events.TickStarted{Action: models.ActionTypeRebalance}})
+	if reverted {
+		m.Handle(context.Background(), events.Event{Time: now, Chain: "local", Pool: "main",
+			Data: events.TxConfirmed{Action: models.ActionTypeRebalance, Reverted: true}})
+	}
+	return m.handle(now, events.TickFinished{Action: models.ActionTypeRebalance, Error: errMsg})
+}
+
+// expireCooldown 把告警上次通知的时间提前到冷却时间之外
+func (m *testManager) expireCooldown(alert Alert) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.alerts[alert.key()]
+	state.lastNotified = state.lastNotified.Add(-m.config.Alert.Cooldown - time.Second)
+}
+
+func expectAlerts(t *testing.T, got []Alert, want ...Status) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("收到 %d 条通知 %+v, want %v", len(got), got, want)
+	}
+	for i, status := range want {
+		if got[i].Status != status {
+			t.Errorf("第 %d 条通知状态 %s, want %s", i+1, got[i].Status, status)
+		}
+	}
+}
+
+func TestFailedRebalancesFireAndResolve(t *testing.T) {
+	m := newTestManager(time.Hour)
+
+	expectAlerts(t, m.rebalanceTick("读取储备失败", false))
+	expectAlerts(t, m.rebalanceTick("", true))
+	fired := m.rebalanceTick("发送交易失败", false)
+	expectAlerts(t, fired, StatusFiring)
+	alert := fired[0]
+	if alert.Rule != RuleFailedRebalances || alert.Severity != SeverityCritical || alert.Chain != "local" || alert.Pool != "main" {
+		t.Errorf("告警 = %+v", alert)
+	}
+	if !strings.Contains(alert.Summary, "连续失败 3 次") || !strings.Contains(alert.Summary, "发送交易失败") {
+		t.Errorf("summary = %q", alert.Summary)
+	}
+
+	resolved := m.rebalanceTick("", false)
+	expectAlerts(t, resolved, StatusResolved)
+	if !resolved[0].StartsAt.Equal(alert.StartsAt) || resolved[0].Severity != SeverityCritical {
+		t.Errorf("恢复通知应沿用触发时的开始时间和级别: %+v", resolved[0])
+	}
+
+	// 恢复后重新计数
+	expectAlerts(t, m.rebalanceTick("x", false))
+	expectAlerts(t, m.rebalanceTick("x", false))
+}
+
+func TestFailedRebalancesRevertedWithoutError(t *testing.T) {
+	m := newTestManager(0)
+	for i := 0; i < 2; i++ {
+		expectAlerts(t, m.rebalanceTick("", true))
+	}
+	fired := m.rebalanceTick("", true)
+	expectAlerts(t, fired, StatusFiring)
+	if !strings.Contains(fired[0].Summary, "revert") {
+		t.Errorf("summary = %q", fired[0].Summary)
+	}
+}
+
+func TestRefireDuringCooldownSuppressed(t *testing.T) {
+	m := newTestManager(time.Hour)
+	for i := 0; i < 2; i++ {
+		m.rebalanceTick("x", false)
+	}
+	fired := m.rebalanceTick("x", false)
+	expectAlerts(t, fired, StatusFiring)
+
+	// 持续失败，冷却时间内不重复通知
+	expectAlerts(t, m.rebalanceTick("x", false))
+	expectAlerts(t, m.rebalanceTick("x", false))
+
+	m.expireCooldown(fired[0])
+	refired := m.rebalanceTick("x", false)
+	expectAlerts(t, refired, StatusFiring)
+	if !refired[0].StartsAt.Equal(fired[0].StartsAt) {
+		t.Errorf("持续触发的告警开始时间应保持不变: %v != %v", refired[0].StartsAt, fired[0].StartsAt)
+	}
+	if !strings.Contains(refired[0].Summary, "连续失败 6 次") {
+		t.Errorf("summary = %q", refired[0].Summary)
+	}
+}
+
+func TestResolvedOnlyAfterFiringNotified(t *testing.T) {
+	m := newTestManager(time.Hour)
+	m.config.Pools[0].AlertFailedRebalances = 1
+
+	expectAlerts(t, m.rebalanceTick("x", false), StatusFiring)
+	expectAlerts(t, m.rebalanceTick("", false), StatusResolved)
+
+	// 冷却时间内再次触发：不通知，随后的恢复也不通知
+	expectAlerts(t, m.rebalanceTick("x", false))
+	expectAlerts(t, m.rebalanceTick("", false))
+
+	// 没有触发过的告警恢复时不通知
+	expectAlerts(t, m.rebalanceTick("", false))
+
+	// 冷却结束后的触发和恢复都会通知
+	m.expireCooldown(Alert{Rule: RuleFailedRebalances, Chain: "local", Pool: "main"})
+	expectAlerts(t, m.rebalanceTick("x", false), StatusFiring)
+	expectAlerts(t, m.rebalanceTick("", false), StatusResolved)
+}
+
+func TestRPCFailoverClearsFiring(t *testing.T) {
+	m := newTestManager(time.Hour)
+	failover := events.RPCFailover{From: "a.example", To: "b.example", Reason: "超时"}
+
+	fired := m.handle(time.Now(), failover)
+	expectAlerts(t, fired, StatusFiring)
+	if fired[0].Rule != RuleRPCFailover || fired[0].Pool != "" || !strings.Contains(fired[0].Summary, "a.example") {
+		t.Errorf("告警 = %+v", fired[0])
+	}
+	state := m.alerts[fired[0].key()]
+	if state.firing || state.notified {
+		t.Errorf("节点切换告警发送后应清除触发状态: %+v", state)
+	}
+
+	// 冷却时间内的再次切换不通知，每次切换都重新开始
+	expectAlerts(t, m.handle(time.Now(), failover))
+	m.expireCooldown(fired[0])
+	expectAlerts(t, m.handle(time.Now(), failover), StatusFiring)
+	if state.firing {
+		t.Errorf("再次切换后仍应清除触发状态")
+	}
+
+	// 其他链的切换是另一条告警
+	m.Handle(context.Background(), events.Event{Time: time.Now(), Chain: "sepolia", Data: failover})
+	expectAlerts(t, m.flush(), StatusFiring)
+
+	m.config.Alert.RPCFailover = false
+	m.expireCooldown(fired[0])
+	expectAlerts(t, m.handle(time.Now(), failover))
+}
+
+func TestDeviationWindow(t *testing.T) {
+	m := newTestManager(0)
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	deviation := func(minutes int, value float64) []Alert {
+		return m.handle(start.Add(time.Duration(minutes)*time.Minute), events.PoolStateUpdated{Deviation: value})
+	}
+
+	expectAlerts(t, deviation(0, 0.06))
+	expectAlerts(t, deviation(5, 0.08))
+	expectAlerts(t, deviation(9, 0.06))
+	fired := deviation(10, 0.07)
+	expectAlerts(t, fired, StatusFiring)
+	if fired[0].Rule != RuleDeviation || fired[0].Severity != SeverityWarning || !strings.Contains(fired[0].Summary, "已持续 10m0s") {
+		t.Errorf("告警 = %+v", fired[0])
+	}
+
+	// 等于阈值视为未超过，恢复后重新计时
+	expectAlerts(t, deviation(11, 0.05), StatusResolved)
+	expectAlerts(t, deviation(12, 0.06))
+	expectAlerts(t, deviation(21, 0.06))
+	expectAlerts(t, deviation(22, 0.06), StatusFiring)
+}
+
+func TestDeviationWindowResetsBeforeFiring(t *testing.T) {
+	m := newTestManager(0)
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	deviation := func(minutes int, value float64) []Alert {
+		return m.handle(start.Add(time.Duration(minutes)*time.Minute), events.PoolStateUpdated{Deviation: value})
+	}
+
+	expectAlerts(t, deviation(0, 0.06))
+	expectAlerts(t, deviation(8, 0.01))
+	expectAlerts(t, deviation(9, 0.06))
+	expectAlerts(t, deviation(15, 0.06))
+	expectAlerts(t, deviation(19, 0.06), StatusFiring)
+
+	m.config.Pools[0].AlertDeviationThreshold = 0
+	expectAlerts(t, deviation(30, 0.5))
+}
+
+func TestOracleStaleResolvedByPoolState(t *testing.T) {
+	m := newTestManager(time.Hour)
+	updated := time.Now().Add(-10 * time.Minute)
+
+	fired := m.handle(time.Now(), events.OracleStale{LastUpdated: &updated, MaxAgeSeconds: 300, Error: "请求超时"})
+	expectAlerts(t, fired, StatusFiring)
+	if fired[0].Rule != RuleOracleStale || !strings.Contains(fired[0].Summary, "上限 300s") {
+		t.Errorf("告警 = %+v", fired[0])
+	}
+	expectAlerts(t, m.handle(time.Now(), events.OracleStale{MaxAgeSeconds: 300, Error: "请求超时"}))
+	expectAlerts(t, m.handle(time.Now(), events.PoolStateUpdated{}), StatusResolved)
+
+	m.config.Alert.OracleStale = false
+	m.expireCooldown(fired[0])
+	expectAlerts(t, m.handle(time.Now(), events.OracleStale{Error: "请求超时"}))
+}
+
+type fakeBalance struct {
+	balance *big.Int
+	err     error
+}
+
+func (b *fakeBalance) GetBalance() (*big.Int, error) { return b.balance, b.err }
+
+func (b *fakeBalance) GetFromAddress() common.Address {
+	return common.HexToAddress("0x00000000000000000000000000000000000000aa")
+}
+
+func TestLowBalance(t *testing.T) {
+	m := newTestManager(time.Hour)
+	chain := m.config.Chains[0]
+	chain.AlertMinBalance = big.NewInt(1e17)
+	reader := &fakeBalance{balance: big.NewInt(5e16)}
+	watch := balanceWatch{chain: chain, reader: reader}
+
+	m.checkBalance(watch)
+	fired := m.flush()
+	expectAlerts(t, fired, StatusFiring)
+	if fired[0].Rule != RuleLowBalance || fired[0].Pool != "" || !strings.Contains(fired[0].Summary, "0.050000 ETH") {
+		t.Errorf("告警 = %+v", fired[0])
+	}
+
+	// 查询失败时保持原状态
+	reader.err = errors.New("RPC 不可用")
+	m.checkBalance(watch)
+	expectAlerts(t, m.flush())
+
+	reader.balance, reader.err = big.NewInt(1e17), nil
+	m.checkBalance(watch)
+	expectAlerts(t, m.flush(), StatusResolved)
+
+	chain.AlertMinBalance = big.NewInt(0)
+	reader.balance = big.NewInt(0)
+	m.checkBalance(watch)
+	expectAlerts(t, m.flush())
+}
+
+func TestSinkFailureKeepsState(t *testing.T) {
+	m := newTestManager(time.Hour)
+	m.config.Pools[0].AlertFailedRebalances = 1
+	m.sink.err = errors.New("HTTP 500")
+
+	// 发送失败只记录日志和指标，告警仍视为已通知，恢复时照常发送
+	expectAlerts(t, m.rebalanceTick("x", false), StatusFiring)
+	expectAlerts(t, m.rebalanceTick("", false), StatusResolved)
+}
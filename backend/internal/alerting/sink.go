@@ -0,0 +1,186 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	util "mini-amm-bot/internal/util"
+)
+
+// Sink 告警通知渠道
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// NewSinks 按配置创建通知渠道，未配置任何渠道时返回空切片
+func NewSinks(config util.AlertConfig) []Sink {
+	sinks := []Sink{}
+	if config.WebhookURL != "" {
+		sinks = append(sinks, &WebhookSink{url: config.WebhookURL})
+	}
+	if config.SlackWebhookURL != "" {
+		sinks = append(sinks, &SlackSink{url: config.SlackWebhookURL})
+	}
+	if config.SMTPHost != "" {
+		sinks = append(sinks, &EmailSink{
+			host:     config.SMTPHost,
+			port:     config.SMTPPort,
+			username: config.SMTPUsername,
+			password: config.SMTPPassword,
+			from:     config.SMTPFrom,
+			to:       config.EmailTo,
+		})
+	}
+	return sinks
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookSink 把告警以 JSON POST 到任意 HTTP 地址
+type WebhookSink struct {
+	url string
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, s.url, alert)
+}
+
+// SlackSink 发送到 Slack incoming webhook，Mattermost、Rocket.Chat 等兼容 {"text": ...} 的服务同样可用
+type SlackSink struct {
+	url string
+}
+
+func (s *SlackSink) Name() string { return "slack" }
+
+func (s *SlackSink) Send(ctx context.Context, alert Alert) error {
+	icon := ":rotating_light:"
+	if alert.Status == StatusResolved {
+		icon = ":white_check_mark:"
+	}
+	return postJSON(ctx, s.url, map[string]string{"text": icon + " " + alert.Text()})
+}
+
+func postJSON(ctx context.Context, url string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// EmailSink 通过 SMTP 发送纯文本邮件；465 端口使用隐式 TLS，其他端口在服务器支持时升级 STARTTLS
+type EmailSink struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func (s *EmailSink) Name() string { return "email" }
+
+func (s *EmailSink) Send(ctx context.Context, alert Alert) error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	tlsConfig := &tls.Config{ServerName: s.host}
+
+	var conn net.Conn
+	var err error
+	if s.port == 465 {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && s.port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("认证失败: %w", err)
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("收件人 %s: %w", to, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(s.message(alert)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *EmailSink) message(alert Alert) []byte {
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", s.from},
+		{"To", strings.Join(s.to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", alert.Title())},
+		{"Date", alert.Time.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", header[0], header[1])
+	}
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(alert.Text(), "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return msg.Bytes()
+}
@@ -0,0 +1,325 @@
+package alerting
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	util "mini-amm-bot/internal/util"
+)
+
+func testAlert(status Status) Alert {
+	startsAt := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	return Alert{
+		Rule:     RuleFailedRebalances,
+		Status:   status,
+		Severity: SeverityCritical,
+		Chain:    "local",
+		Pool:     "main",
+		Summary:  "再平衡已连续失败 3 次，最近一次: 交易执行失败 (revert)",
+		StartsAt: startsAt,
+		Time:     startsAt.Add(90 * time.Second),
+	}
+}
+
+// captureServer 记录收到的一个请求，返回 status
+func captureServer(t *testing.T, status int) (*httptest.Server, <-chan *http.Request, <-chan []byte) {
+	t.Helper()
+	requests, bodies := make(chan *http.Request, 1), make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(status)
+		io.WriteString(w, "  rejected  ")
+	}))
+	t.Cleanup(server.Close)
+	return server, requests, bodies
+}
+
+func TestWebhookSink(t *testing.T) {
+	server, requests, bodies := captureServer(t, http.StatusNoContent)
+	alert := testAlert(StatusFiring)
+
+	if err := (&WebhookSink{url: server.URL + "/hook"}).Send(context.Background(), alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	req, body := <-requests, <-bodies
+	if req.Method != http.MethodPost || req.URL.Path != "/hook" || req.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("请求 %s %s, Content-Type %q", req.Method, req.URL.Path, req.Header.Get("Content-Type"))
+	}
+
+	var got Alert
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("解析请求体失败: %v\n%s", err, body)
+	}
+	if got != alert {
+		t.Errorf("请求体 %+v, want %+v", got, alert)
+	}
+	for _, field := range []string{`"rule":"failed_rebalances"`, `"status":"firing"`, `"severity":"critical"`, `"startsAt":"2026-10-19T12:00:00Z"`} {
+		if !bytes.Contains(body, []byte(field)) {
+			t.Errorf("请求体缺少 %s: %s", field, body)
+		}
+	}
+}
+
+func TestSlackSink(t *testing.T) {
+	for status, icon := range map[Status]string{StatusFiring: ":rotating_light:", StatusResolved: ":white_check_mark:"} {
+		t.Run(string(status), func(t *testing.T) {
+			server, requests, bodies := captureServer(t, http.StatusOK)
+			alert := testAlert(status)
+
+			if err := (&SlackSink{url: server.URL}).Send(context.Background(), alert); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if req := <-requests; req.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("Content-Type %q", req.Header.Get("Content-Type"))
+			}
+			var payload map[string]string
+			if err := json.Unmarshal(<-bodies, &payload); err != nil {
+				t.Fatalf("解析请求体失败: %v", err)
+			}
+			if want := icon + " " + alert.Text(); payload["text"] != want || len(payload) != 1 {
+				t.Errorf("请求体 %q, want {\"text\": %q}", payload, want)
+			}
+		})
+	}
+}
+
+func TestPostJSONErrorStatus(t *testing.T) {
+	server, _, _ := captureServer(t, http.StatusBadRequest)
+	err := (&SlackSink{url: server.URL}).Send(context.Background(), testAlert(StatusFiring))
+	if err == nil || err.Error() != "HTTP 400: rejected" {
+		t.Errorf("err = %v, want HTTP 400: rejected", err)
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	sink := &EmailSink{from: "keeper@example.com", to: []string{"ops@example.com", "oncall@example.com"}}
+	for _, status := range []Status{StatusFiring, StatusResolved} {
+		t.Run(string(status), func(t *testing.T) {
+			alert := testAlert(status)
+			raw := sink.message(alert)
+			if bytes.Contains(bytes.ReplaceAll(raw, []byte("\r\n"), nil), []byte("\n")) {
+				t.Errorf("邮件应使用 CRLF 换行: %q", raw)
+			}
+			checkEmail(t, raw, sink, alert)
+		})
+	}
+}
+
+// checkEmail 检查邮件头和正文
+func checkEmail(t *testing.T, raw []byte, sink *EmailSink, alert Alert) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("解析邮件失败: %v\n%s", err, raw)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != alert.Title() {
+		t.Errorf("Subject %q (%v), want %q", subject, err, alert.Title())
+	}
+	if strings.ContainsFunc(msg.Header.Get("Subject"), func(r rune) bool { return r > 127 }) {
+		t.Errorf("Subject 应按 RFC 2047 编码: %q", msg.Header.Get("Subject"))
+	}
+	date, err := msg.Header.Date()
+	if err != nil || !date.Equal(alert.Time) {
+		t.Errorf("Date %v (%v), want %v", date, err, alert.Time)
+	}
+	for header, want := range map[string]string{
+		"From":                      sink.from,
+		"To":                        strings.Join(sink.to, ", "),
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding": "8bit",
+	} {
+		if got := msg.Header.Get(header); got != want {
+			t.Errorf("%s %q, want %q", header, got, want)
+		}
+	}
+
+	body, _ := io.ReadAll(msg.Body)
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != alert.Text()+"\n" {
+		t.Errorf("正文 %q, want %q", got, alert.Text())
+	}
+}
+
+// smtpSession 测试用 SMTP 服务收到的一次会话
+type smtpSession struct {
+	commands []string
+	auth     string // AUTH PLAIN 解码后的凭据
+	data     []byte
+}
+
+// fakeSMTP 只处理一个连接的最小 SMTP 服务：不支持 STARTTLS，rejectRcpt 中的收件人返回 550
+func fakeSMTP(t *testing.T, rejectRcpt string) (string, int, <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		var session smtpSession
+		defer func() { sessions <- session }()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 localhost ESMTP test")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			session.commands = append(session.commands, line)
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case verb == "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case verb == "AUTH":
+				credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+				session.auth = string(credentials)
+				reply("235 2.7.0 Authentication successful")
+			case verb == "RCPT" && rejectRcpt != "" && strings.Contains(line, rejectRcpt):
+				reply("550 5.1.1 no such user")
+			case verb == "MAIL" || verb == "RCPT":
+				reply("250 OK")
+			case verb == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data bytes.Buffer
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(strings.TrimPrefix(dataLine, "."))
+				}
+				session.data = data.Bytes()
+				reply("250 OK")
+			case verb == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, sessions
+}
+
+func TestEmailSink(t *testing.T) {
+	host, port, sessions := fakeSMTP(t, "")
+	sink := &EmailSink{
+		host:     host,
+		port:     port,
+		username: "keeper",
+		password: "secret",
+		from:     "keeper@example.com",
+		to:       []string{"ops@example.com", "oncall@example.com"},
+	}
+	alert := testAlert(StatusResolved)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sink.Send(ctx, alert); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	session := <-sessions
+
+	if session.auth != "\x00keeper\x00secret" {
+		t.Errorf("AUTH PLAIN 凭据 %q", session.auth)
+	}
+	var verbs []string
+	for _, command := range session.commands {
+		verbs = append(verbs, strings.SplitN(command, " ", 2)[0])
+	}
+	if got, want := strings.Join(verbs, " "), "EHLO AUTH MAIL RCPT RCPT DATA QUIT"; got != want {
+		t.Errorf("SMTP 命令 %q, want %q", got, want)
+	}
+	for _, want := range []string{"MAIL FROM:<keeper@example.com>", "RCPT TO:<ops@example.com>", "RCPT TO:<oncall@example.com>"} {
+		if !strings.Contains(strings.Join(session.commands, "\n"), want) {
+			t.Errorf("缺少命令 %s: %q", want, session.commands)
+		}
+	}
+	checkEmail(t, session.data, sink, alert)
+}
+
+func TestEmailSinkWithoutAuth(t *testing.T) {
+	host, port, sessions := fakeSMTP(t, "")
+	sink := &EmailSink{host: host, port: port, from: "keeper@example.com", to: []string{"ops@example.com"}}
+
+	if err := sink.Send(context.Background(), testAlert(StatusFiring)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for _, command := range (<-sessions).commands {
+		if strings.HasPrefix(command, "AUTH") {
+			t.Errorf("未设置用户名时不应认证: %q", command)
+		}
+	}
+}
+
+func TestEmailSinkRejectedRecipient(t *testing.T) {
+	host, port, sessions := fakeSMTP(t, "nobody@example.com")
+	sink := &EmailSink{host: host, port: port, from: "keeper@example.com", to: []string{"ops@example.com", "nobody@example.com"}}
+
+	err := sink.Send(context.Background(), testAlert(StatusFiring))
+	if err == nil || !strings.Contains(err.Error(), "收件人 nobody@example.com") {
+		t.Errorf("err = %v", err)
+	}
+	if session := <-sessions; session.data != nil {
+		t.Errorf("收件人被拒绝时不应发送正文")
+	}
+}
+
+func TestNewSinks(t *testing.T) {
+	if sinks := NewSinks(util.AlertConfig{}); len(sinks) != 0 {
+		t.Errorf("未配置渠道时应返回空切片: %v", sinks)
+	}
+
+	sinks := NewSinks(util.AlertConfig{
+		WebhookURL:      "http://hook.example",
+		SlackWebhookURL: "https://hooks.slack.example",
+		SMTPHost:        "smtp.example",
+		SMTPPort:        587,
+		SMTPFrom:        "keeper@example.com",
+		EmailTo:         []string{"ops@example.com"},
+	})
+	var names []string
+	for _, sink := range sinks {
+		names = append(names, sink.Name())
+	}
+	if got := strings.Join(names, ","); got != "webhook,slack,email" {
+		t.Errorf("渠道 %s, want webhook,slack,email", got)
+	}
+	if email := sinks[2].(*EmailSink); email.host != "smtp.example" || email.port != 587 || email.to[0] != "ops@example.com" {
+		t.Errorf("EmailSink = %+v", email)
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"mini-amm-bot/internal/logging"
+)
+
+// requireAdmin 校验 Authorization: Bearer <ADMIN_TOKEN>，未配置 ADMIN_TOKEN 时管理接口不可用
+func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if h.config.AdminToken == "" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Admin API disabled: ADMIN_TOKEN not set"})
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AdminToken)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unauthorized"})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// SetLogLevelRequest 调整日志级别的请求体，Component 为空时调整默认级别
+type SetLogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+// LogLevels GET 返回默认级别和各组件当前级别，PUT 运行时调整级别
+func (h *Handler) LogLevels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req SetLogLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid request body: " + err.Error()})
+			return
+		}
+		if err := logging.SetLevel(req.Component, req.Level); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		component := req.Component
+		if component == "" {
+			component = "default"
+		}
+		logger.WithFields(map[string]interface{}{"target": component, "level": req.Level}).Info("日志级别已调整")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"levels":  logging.Levels(),
+	})
+}
+
+// SetPausedRequest 暂停/恢复的请求体，Pool 为空时作用于所有池
+type SetPausedRequest struct {
+	Pool   string `json:"pool"`
+	Paused bool   `json:"paused"`
+}
+
+// Pause GET 返回各池是否暂停，PUT 暂停或恢复池的复投与再平衡交易。
+// 暂停即只读模式：继续检查和记录指标，但不发送交易。
+// 启动自检失败而只读的池不能在这里恢复，需修复后重启
+func (h *Handler) Pause(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var req SetPausedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid request body: " + err.Error()})
+			return
+		}
+		if !req.Paused && req.Pool != "" && h.preflightReadOnly(req.Pool) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Pool " + req.Pool + " failed preflight checks and stays read-only until restart"})
+			return
+		}
+		found := false
+		for i, compoundService := range h.compound {
+			if req.Pool != "" && compoundService.Pool().Name != req.Pool {
+				continue
+			}
+			found = true
+			if !req.Paused && h.preflightReadOnly(compoundService.Pool().Name) {
+				continue
+			}
+			compoundService.SetReadOnly(req.Paused)
+			h.rebalance[i].SetReadOnly(req.Paused)
+			logger.WithFields(map[string]interface{}{"pool": compoundService.Pool().Name, "paused": req.Paused}).Info("池交易暂停状态已调整")
+		}
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unknown pool: " + req.Pool})
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	paused := make(map[string]bool, len(h.compound))
+	for i, compoundService := range h.compound {
+		paused[compoundService.Pool().Name] = compoundService.IsReadOnly() || h.rebalance[i].IsReadOnly()
+	}
+	readOnlyPools := []string{}
+	if h.preflight != nil {
+		readOnlyPools = h.preflight.ReadOnlyPools
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       true,
+		"paused":        paused,
+		"readOnlyPools": readOnlyPools,
+	})
+}
+
+// preflightReadOnly 池是否因启动自检失败而处于只读模式
+func (h *Handler) preflightReadOnly(pool string) bool {
+	if h.preflight == nil {
+		return false
+	}
+	for _, name := range h.preflight.ReadOnlyPools {
+		if name == pool {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/export"
+)
+
+// ExportBotActions 流式导出操作记录，格式由路径后缀决定（.csv / .ndjson），
+// 过滤参数与 /api/bot-actions 相同，忽略 limit/offset/cursor，按时间升序导出全部匹配记录
+func (h *Handler) ExportBotActions(w http.ResponseWriter, r *http.Request) {
+	format, ok := h.exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	filter, _, err := parseBotActionFilter(r.URL.Query())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	startExport(w, "bot-actions", format)
+	rows, err := export.BotActions(r.Context(), h.repo, filter, format, w)
+	finishExport(r, "操作记录", rows, err)
+}
+
+// ExportPoolSnapshots 流式导出池状态快照，支持 pool、from、to 参数
+func (h *Handler) ExportPoolSnapshots(w http.ResponseWriter, r *http.Request) {
+	format, ok := h.exportFormat(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := db.SnapshotFilter{}
+	if pool := query.Get("pool"); pool != "" {
+		filter.Pool = &pool
+	}
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		t, err := export.ParseTime(raw)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("invalid %s %q: want RFC3339 or unix seconds", param.name, raw)})
+			return
+		}
+		*param.target = &t
+	}
+
+	startExport(w, "pool-snapshots", format)
+	rows, err := export.PoolSnapshots(r.Context(), h.snapshots, filter, format, w)
+	finishExport(r, "池快照", rows, err)
+}
+
+// exportFormat 校验请求方法并从路径后缀取得导出格式
+func (h *Handler) exportFormat(w http.ResponseWriter, r *http.Request) (export.Format, bool) {
+	if r.Method != "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return "", false
+	}
+
+	format, err := export.ParseFormat(strings.TrimPrefix(path.Ext(r.URL.Path), "."))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return "", false
+	}
+	return format, true
+}
+
+// startExport 写出响应头；导出可能超过服务器的 WriteTimeout，取消本次请求的写超时
+func startExport(w http.ResponseWriter, name string, format export.Format) {
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warnf("取消导出请求的写超时失败: %v", err)
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().UTC().Format("20060102T150405Z"), format))
+	w.WriteHeader(http.StatusOK)
+}
+
+// finishExport 响应头已经发出，中途出错无法再返回错误状态码，
+// 直接中断连接，避免客户端把不完整的文件当作完整导出
+func finishExport(r *http.Request, what string, rows int, err error) {
+	if err != nil {
+		logger.Errorf("导出%s失败 (已写出 %d 行): %v", what, rows, err)
+		panic(http.ErrAbortHandler)
+	}
+	logger.Infof("导出%s %d 行 (%s)", what, rows, r.URL.RequestURI())
+}
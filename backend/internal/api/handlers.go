@@ -2,21 +2,38 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
 	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/export"
+	"mini-amm-bot/internal/health"
 	"mini-amm-bot/internal/models"
+	"mini-amm-bot/internal/scheduler"
+	"mini-amm-bot/internal/services"
 	"mini-amm-bot/internal/util"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Handler struct {
-	repo   *db.BotActionRepository
-	config *util.Config
+	repo      db.BotActionStore
+	snapshots db.PoolSnapshotStore
+	bus       *events.Bus
+	config    *util.Config
+	preflight *services.PreflightReport
+	readiness *health.Checker
+	scheduler *scheduler.Scheduler
+	compound  []*services.CompoundService
+	rebalance []*services.RebalanceService
 }
 
-func NewHandler(repo *db.BotActionRepository, config *util.Config) *Handler {
-	return &Handler{repo: repo, config: config}
+func NewHandler(repo db.BotActionStore, snapshots db.PoolSnapshotStore, bus *events.Bus, config *util.Config, preflight *services.PreflightReport, readiness *health.Checker, sched *scheduler.Scheduler, compoundServices []*services.CompoundService, rebalanceServices []*services.RebalanceService) *Handler {
+	return &Handler{repo: repo, snapshots: snapshots, bus: bus, config: config, preflight: preflight, readiness: readiness, scheduler: sched, compound: compoundServices, rebalance: rebalanceServices}
 }
 
 type ErrorResponse struct {
@@ -40,16 +57,60 @@ func (h *Handler) GetBotActions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := r.URL.Query()
+	filter, limit, err := parseBotActionFilter(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	filter := db.QueryFilter{
-		Limit:  10, // Default limit
-		Offset: 0,
+	// 多取一条判断是否还有下一页
+	filter.Limit = limit + 1
+	actions, err := h.repo.List(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
 	}
+	total, err := h.repo.Count(filter)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	hasMore := len(actions) > limit
+	nextCursor := ""
+	if hasMore {
+		actions = actions[:limit]
+		nextCursor = db.CursorAfter(&actions[limit-1]).Encode()
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"data":       actions,
+		"count":      len(actions),
+		"total":      total,
+		"hasMore":    hasMore,
+		"nextCursor": nextCursor,
+	})
+}
+
+// 每页默认与最大记录数
+const (
+	defaultPageSize = 10
+	maxPageSize     = 200
+)
 
+// parseBotActionFilter 解析 /api/bot-actions 的查询参数，返回过滤条件与每页记录数；
+// 旧参数（limit/offset/chainId/type）取值无效时沿用原来的忽略行为，新参数无效时返回错误
+func parseBotActionFilter(query url.Values) (db.QueryFilter, int, error) {
+	filter := db.QueryFilter{}
+
+	limit := defaultPageSize
 	if limitStr := query.Get("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
-			filter.Limit = limit
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = min(parsed, maxPageSize)
 		}
 	}
 
@@ -59,6 +120,16 @@ func (h *Handler) GetBotActions(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if pool := query.Get("pool"); pool != "" {
+		filter.Pool = &pool
+	}
+
+	if chainIDStr := query.Get("chainId"); chainIDStr != "" {
+		if chainID, err := strconv.ParseInt(chainIDStr, 10, 64); err == nil {
+			filter.ChainID = &chainID
+		}
+	}
+
 	if actionTypeStr := query.Get("type"); actionTypeStr != "" {
 		actionType := models.ActionType(actionTypeStr)
 		if actionType == models.ActionTypeCompound || actionType == models.ActionTypeRebalance {
@@ -66,18 +137,60 @@ func (h *Handler) GetBotActions(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	actions, err := h.repo.List(filter)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
-		return
+	if status := query.Get("status"); status != "" {
+		if status != "success" && status != "failed" {
+			return filter, 0, fmt.Errorf("invalid status %q: want success or failed", status)
+		}
+		filter.Status = &status
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    actions,
-		"count":   len(actions),
-	})
+	if direction := query.Get("direction"); direction != "" {
+		if direction != "AtoB" && direction != "BtoA" {
+			return filter, 0, fmt.Errorf("invalid direction %q: want AtoB or BtoA", direction)
+		}
+		filter.Direction = &direction
+	}
+
+	if txHash := query.Get("txHash"); txHash != "" {
+		if decoded, err := hexutil.Decode(txHash); err != nil || len(decoded) != 32 {
+			return filter, 0, fmt.Errorf("invalid txHash %q", txHash)
+		}
+		txHash = strings.ToLower(txHash)
+		filter.TxHash = &txHash
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		t, err := export.ParseTime(raw)
+		if err != nil {
+			return filter, 0, fmt.Errorf("invalid %s %q: want RFC3339 or unix seconds", param.name, raw)
+		}
+		*param.target = &t
+	}
+
+	if minAmountStr := query.Get("minAmount"); minAmountStr != "" {
+		minAmount, ok := new(big.Int).SetString(minAmountStr, 10)
+		if !ok || minAmount.Sign() < 0 {
+			return filter, 0, fmt.Errorf("invalid minAmount %q: want a non-negative integer in token base units", minAmountStr)
+		}
+		filter.MinAmount = minAmount
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := db.DecodeCursor(cursorStr)
+		if err != nil {
+			return filter, 0, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, limit, nil
 }
 
 func (h *Handler) GetBotStats(w http.ResponseWriter, r *http.Request) {
@@ -97,33 +210,132 @@ func (h *Handler) GetBotStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	compoundCount, err := h.repo.CountByType(models.ActionTypeCompound)
+	query := r.URL.Query()
+	filter, _, err := parseBotActionFilter(query)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
+	bucket, err := parseBucket(query.Get("bucket"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+	pool := filter.Pool
 
-	rebalanceCount, err := h.repo.CountByType(models.ActionTypeRebalance)
+	stats, err := h.poolStats(pool)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	latestAction, err := h.repo.GetLatestAction()
+	// summary 为过滤条件与时间范围内的汇总，指定 bucket 时 buckets 为按时间段拆分的统计
+	aggregated, err := h.repo.Aggregate(r.Context(), filter, 0)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
 		return
 	}
+	summary := aggregated[0]
+	summary.Start, summary.End = filter.From, filter.To
+	summary.GasCostEth = export.WeiToEther(summary.GasCostWei)
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	ret := map[string]interface{}{
 		"success":        true,
-		"compoundCount":  compoundCount,
-		"rebalanceCount": rebalanceCount,
-		"latestAction":   latestAction,
-	})
+		"compoundCount":  stats.CompoundCount,
+		"rebalanceCount": stats.RebalanceCount,
+		"latestAction":   stats.LatestAction,
+		"summary":        summary,
+	}
+
+	if bucket > 0 {
+		buckets, err := h.repo.Aggregate(r.Context(), filter, bucket)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		for i := range buckets {
+			buckets[i].GasCostEth = export.WeiToEther(buckets[i].GasCostWei)
+		}
+		ret["bucket"] = query.Get("bucket")
+		ret["buckets"] = buckets
+	}
+
+	// 未指定池时额外返回按池拆分的统计
+	if pool == nil {
+		pools := make(map[string]*PoolStats, len(h.config.Pools))
+		for _, p := range h.config.Pools {
+			name := p.Name
+			poolStats, err := h.poolStats(&name)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+				return
+			}
+			poolStats.Chain = p.Chain
+			if chain := h.config.GetChain(p.Chain); chain != nil {
+				poolStats.ChainID = chain.ChainID
+			}
+			pools[name] = poolStats
+		}
+		ret["pools"] = pools
+	}
+
+	json.NewEncoder(w).Encode(ret)
+}
+
+// parseBucket 解析统计时间段：hour、day、week 或 Go duration（如 15m、6h），至少 1 分钟且为整分钟；为空时不拆分
+func parseBucket(raw string) (time.Duration, error) {
+	switch raw {
+	case "":
+		return 0, nil
+	case "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	case "week":
+		return 7 * 24 * time.Hour, nil
+	}
+	bucket, err := time.ParseDuration(raw)
+	if err != nil || bucket < time.Minute || bucket%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid bucket %q: want hour, day, week or a whole number of minutes such as 15m", raw)
+	}
+	return bucket, nil
+}
+
+type PoolStats struct {
+	Chain          string            `json:"chain,omitempty"`
+	ChainID        int64             `json:"chainId,omitempty"`
+	CompoundCount  int64             `json:"compoundCount"`
+	RebalanceCount int64             `json:"rebalanceCount"`
+	LatestAction   *models.BotAction `json:"latestAction"`
+}
+
+func (h *Handler) poolStats(pool *string) (*PoolStats, error) {
+	compoundCount, err := h.repo.CountByType(models.ActionTypeCompound, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	rebalanceCount, err := h.repo.CountByType(models.ActionTypeRebalance, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	latestAction, err := h.repo.GetLatestAction(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PoolStats{
+		CompoundCount:  compoundCount,
+		RebalanceCount: rebalanceCount,
+		LatestAction:   latestAction,
+	}, nil
 }
 
 func (h *Handler) GetBotConfig(w http.ResponseWriter, r *http.Request) {
@@ -143,17 +355,64 @@ func (h *Handler) GetBotConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	config, _ := util.LoadConfig()
+	config := h.config
+	poolFilter := r.URL.Query().Get("pool")
+
+	chains := []map[string]interface{}{}
+	for _, chain := range config.Chains {
+		chains = append(chains, map[string]interface{}{
+			"name":                  chain.Name,
+			"chainId":               chain.ChainID,
+			"gasLimit":              chain.GasLimit,
+			"maxGasPrice":           chain.MaxGasPrice,
+			"confirmations":         chain.Confirmations,
+			"confirmTimeoutSeconds": chain.ConfirmTimeout.Seconds(),
+		})
+	}
+
+	pools := []map[string]interface{}{}
+	for _, pool := range config.Pools {
+		if poolFilter != "" && pool.Name != poolFilter {
+			continue
+		}
+		var chainID int64
+		if chain := config.GetChain(pool.Chain); chain != nil {
+			chainID = chain.ChainID
+		}
+		pools = append(pools, map[string]interface{}{
+			"name":               pool.Name,
+			"chain":              pool.Chain,
+			"chainId":            chainID,
+			"contractAddress":    pool.ContractAddress,
+			"network":            pool.Network,
+			"tokenA":             pool.TokenAAddress,
+			"tokenB":             pool.TokenBAddress,
+			"deploymentBlock":    pool.DeploymentBlock,
+			"compoundInterval":   int(pool.CompoundInterval / time.Second),
+			"rebalanceInterval":  int(pool.RebalanceInterval / time.Second),
+			"compoundSchedule":   pool.CompoundSchedule,
+			"rebalanceSchedule":  pool.RebalanceSchedule,
+			"rebalanceThreshold": pool.RebalanceThreshold,
+			"targetValueShare":   pool.TargetValueShare,
+		})
+	}
 
 	ret := map[string]interface{}{
-		"compoundInterval":   int(config.CompoundInterval / time.Second),
-		"rebalanceInterval":  int(config.RebalanceInterval / time.Second),
-		"rebalanceThreshold": config.RebalanceThreshold,
-		"gasLimit":           config.GasLimit,
-		"maxGasPrice":        config.MaxGasPrice,
-		"retryAttempts":      config.RetryAttempts,
-		"retryDelay":         config.RetryDelay,
-		"chainId":            config.ChainID,
+		"retryAttempts": config.RetryAttempts,
+		"retryDelay":    config.RetryDelay,
+		"chains":        chains,
+		"pools":         pools,
+	}
+
+	// 兼容单池/单链时代的字段：取第一个（或指定的）池及其所属链
+	if len(pools) > 0 {
+		for _, key := range []string{"compoundInterval", "rebalanceInterval", "rebalanceThreshold", "chainId"} {
+			ret[key] = pools[0][key]
+		}
+		if chain := config.GetChain(pools[0]["chain"].(string)); chain != nil {
+			ret["gasLimit"] = chain.GasLimit
+			ret["maxGasPrice"] = chain.MaxGasPrice
+		}
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -161,3 +420,182 @@ func (h *Handler) GetBotConfig(w http.ResponseWriter, r *http.Request) {
 		"config":  ret,
 	})
 }
+
+// GetSchedules 返回所有复投/再平衡任务的调度规则及下次执行时间，支持 ?pool= 过滤
+func (h *Handler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	poolFilter := r.URL.Query().Get("pool")
+	jobs := []scheduler.Status{}
+	for _, job := range h.scheduler.Jobs() {
+		if poolFilter != "" && !strings.HasSuffix(job.Name, ":"+poolFilter) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"jobs":    jobs,
+	})
+}
+
+// CompoundIntervalResult 单个池的推荐复投间隔，无法计算时 Error 说明原因
+type CompoundIntervalResult struct {
+	Pool           string                           `json:"pool"`
+	Recommendation *services.CompoundRecommendation `json:"recommendation,omitempty"`
+	Error          string                           `json:"error,omitempty"`
+}
+
+// GetCompoundInterval 计算各池推荐的复投间隔及其依据，支持 ?pool= 过滤
+func (h *Handler) GetCompoundInterval(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	poolFilter := r.URL.Query().Get("pool")
+	results := []CompoundIntervalResult{}
+	for _, compoundService := range h.compound {
+		pool := compoundService.Pool().Name
+		if poolFilter != "" && pool != poolFilter {
+			continue
+		}
+		result := CompoundIntervalResult{Pool: pool}
+		rec, err := compoundService.RecommendInterval(r.Context())
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Recommendation = rec
+		}
+		results = append(results, result)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"pools":   results,
+	})
+}
+
+// FeeRemainderPoint 一次复投后的剩余手续费
+type FeeRemainderPoint struct {
+	Timestamp   time.Time `json:"timestamp"`
+	TxHash      string    `json:"txHash"`
+	CompoundedA string    `json:"compoundedA"`
+	CompoundedB string    `json:"compoundedB"`
+	LPMinted    string    `json:"lpMinted"`
+	RemainderA  string    `json:"remainderA"`
+	RemainderB  string    `json:"remainderB"`
+}
+
+// GetFeeRemainders 返回各池最近 ?hours= 小时（默认 168）内每次复投后剩余手续费的变化，支持 ?pool= 过滤
+func (h *Handler) GetFeeRemainders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	query := r.URL.Query()
+	hours := 168
+	if hoursStr := query.Get("hours"); hoursStr != "" {
+		if parsed, err := strconv.Atoi(hoursStr); err == nil && parsed > 0 {
+			hours = parsed
+		}
+	}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	poolFilter := query.Get("pool")
+
+	pools := map[string][]FeeRemainderPoint{}
+	for _, pool := range h.config.Pools {
+		if poolFilter != "" && pool.Name != poolFilter {
+			continue
+		}
+		actions, err := h.repo.ListSuccessfulSince(r.Context(), pool.Name, models.ActionTypeCompound, since)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		points := []FeeRemainderPoint{}
+		for _, action := range actions {
+			// 早于剩余手续费记录功能的复投没有剩余数据
+			if action.FeeRemainderA == "" && action.FeeRemainderB == "" {
+				continue
+			}
+			points = append(points, FeeRemainderPoint{
+				Timestamp:   action.Timestamp,
+				TxHash:      action.TxHash,
+				CompoundedA: action.AmountA,
+				CompoundedB: action.AmountB,
+				LPMinted:    action.LPMinted,
+				RemainderA:  action.FeeRemainderA,
+				RemainderB:  action.FeeRemainderB,
+			})
+		}
+		pools[pool.Name] = points
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"since":   since,
+		"pools":   pools,
+	})
+}
+
+// GetHealth 返回启动自检结果；有池降级为只读时 status 为 "degraded"
+func (h *Handler) GetHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := "ok"
+	if h.preflight != nil && len(h.preflight.ReadOnlyPools) > 0 {
+		status = "degraded"
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    status,
+		"preflight": h.preflight,
+	})
+}
+
+// GetLiveness 进程存活检查，只要能响应就返回 200
+func (h *Handler) GetLiveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "alive",
+	})
+}
+
+// GetReadiness 就绪检查，任一组件不健康时返回 503 及各组件明细
+func (h *Handler) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.readiness == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ready"})
+		return
+	}
+
+	report := h.readiness.Run(r.Context())
+	status := "ready"
+	if !report.Ready {
+		status = "unready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     status,
+		"checkedAt":  report.CheckedAt,
+		"components": report.Components,
+	})
+}
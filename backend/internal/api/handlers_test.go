@@ -0,0 +1,419 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/models"
+	"mini-amm-bot/internal/util"
+)
+
+var seedBase = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// seedActions 测试用的操作记录，按插入顺序 ID 为 1-6；记录 2 和 3 的时间相同，用于检查游标的 ID 排序
+var seedActions = []models.BotAction{
+	{Pool: "main", ChainID: 31337, Timestamp: seedBase.Add(10 * time.Minute), ActionType: models.ActionTypeCompound, Status: "success",
+		AmountA: "100", AmountB: "200", GasUsed: 100000, GasCostWei: "1000000000000000"},
+	{Pool: "main", ChainID: 31337, Timestamp: seedBase.Add(20 * time.Minute), ActionType: models.ActionTypeRebalance, Status: "success",
+		Direction: direction("AtoB"), AmountIn: "50", AmountA: "50", AmountB: "90", GasUsed: 80000, GasCostWei: "500000000000000"},
+	{Pool: "main", ChainID: 31337, Timestamp: seedBase.Add(20 * time.Minute), ActionType: models.ActionTypeRebalance, Status: "failed",
+		Direction: direction("BtoA"), AmountIn: "70", AmountA: "0", AmountB: "70"},
+	{Pool: "side", ChainID: 11155111, Timestamp: seedBase.Add(65 * time.Minute), ActionType: models.ActionTypeRebalance, Status: "success",
+		Direction: direction("BtoA"), AmountIn: "30", AmountA: "25", AmountB: "30", GasUsed: 90000, GasCostWei: "700000000000000"},
+	{Pool: "side", ChainID: 11155111, Timestamp: seedBase.Add(2 * time.Hour), ActionType: models.ActionTypeCompound, Status: "failed",
+		AmountA: "0", AmountB: "0", GasUsed: 60000, GasCostWei: "300000000000000"},
+	{Pool: "main", ChainID: 31337, Timestamp: seedBase.Add(24*time.Hour + time.Minute), ActionType: models.ActionTypeCompound, Status: "success",
+		AmountA: "10", AmountB: "20", GasUsed: 100000, GasCostWei: "1000000000000000"},
+}
+
+func direction(d string) *string {
+	return &d
+}
+
+func txHash(id int64) string {
+	return fmt.Sprintf("0x%064x", id)
+}
+
+// testStores 同一组测试分别在内存存储和 SQLite 上运行；SQLite 执行与 PostgreSQL 相同的查询（$n 占位符、键集比较）
+func testStores(t *testing.T) map[string]db.BotActionStore {
+	t.Helper()
+	sqlite, err := db.NewSQLiteDB(":memory:")
+	if err != nil {
+		t.Fatalf("打开 SQLite 失败: %v", err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	if err := sqlite.Migrate(context.Background()); err != nil {
+		t.Fatalf("SQLite 迁移失败: %v", err)
+	}
+
+	stores := map[string]db.BotActionStore{
+		"memory": db.NewMemoryBotActionStore(),
+		"sqlite": db.NewSQLiteBotActionRepository(sqlite.GetDB()),
+	}
+	for name, store := range stores {
+		for i := range seedActions {
+			action := seedActions[i]
+			action.TxHash = txHash(int64(i + 1))
+			if err := store.Create(context.Background(), &action); err != nil {
+				t.Fatalf("[%s] 写入测试记录失败: %v", name, err)
+			}
+		}
+	}
+	return stores
+}
+
+func newTestHandler(store db.BotActionStore) *Handler {
+	config := &util.Config{
+		Chains: []*util.ChainConfig{{Name: "local", ChainID: 31337}, {Name: "sepolia", ChainID: 11155111}},
+		Pools:  []*util.PoolConfig{{Name: "main", Chain: "local"}, {Name: "side", Chain: "sepolia"}},
+	}
+	return NewHandler(store, db.NewMemoryPoolSnapshotStore(), nil, config, nil, nil, nil, nil, nil)
+}
+
+func get(t *testing.T, handler http.HandlerFunc, path string, query url.Values, out interface{}) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, path+"?"+query.Encode(), nil))
+	if out != nil && recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+			t.Fatalf("解析响应失败: %v\n%s", err, recorder.Body.String())
+		}
+	}
+	return recorder.Code
+}
+
+type botActionsResponse struct {
+	Success    bool               `json:"success"`
+	Data       []models.BotAction `json:"data"`
+	Count      int                `json:"count"`
+	Total      int64              `json:"total"`
+	HasMore    bool               `json:"hasMore"`
+	NextCursor string             `json:"nextCursor"`
+}
+
+// ids 响应中记录对应的种子编号，按 txHash 还原，避免依赖各存储分配的 ID
+func ids(actions []models.BotAction) []int64 {
+	result := []int64{}
+	for _, action := range actions {
+		for i := range seedActions {
+			if action.TxHash == txHash(int64(i+1)) {
+				result = append(result, int64(i+1))
+			}
+		}
+	}
+	return result
+}
+
+func TestGetBotActionsFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []int64
+	}{
+		{"全部", url.Values{}, []int64{6, 5, 4, 3, 2, 1}},
+		{"池", url.Values{"pool": {"main"}}, []int64{6, 3, 2, 1}},
+		{"链", url.Values{"chainId": {"11155111"}}, []int64{5, 4}},
+		{"类型", url.Values{"type": {"REBALANCE"}}, []int64{4, 3, 2}},
+		{"状态", url.Values{"status": {"failed"}}, []int64{5, 3}},
+		{"方向", url.Values{"direction": {"BtoA"}}, []int64{4, 3}},
+		{"交易哈希", url.Values{"txHash": {txHash(2)}}, []int64{2}},
+		{"时间范围不含 to", url.Values{
+			"from": {seedBase.Add(20 * time.Minute).Format(time.RFC3339)},
+			"to":   {seedBase.Add(2 * time.Hour).Format(time.RFC3339)},
+		}, []int64{4, 3, 2}},
+		{"unix 秒", url.Values{"from": {strconv.FormatInt(seedBase.Add(time.Hour).Unix(), 10)}}, []int64{6, 5, 4}},
+		{"最小数量", url.Values{"minAmount": {"60"}}, []int64{3, 2, 1}},
+		{"组合条件", url.Values{"pool": {"main"}, "type": {"COMPOUND"}, "status": {"success"}}, []int64{6, 1}},
+		{"无匹配", url.Values{"pool": {"main"}, "chainId": {"11155111"}}, []int64{}},
+		{"无效的旧参数被忽略", url.Values{"type": {"swap"}, "chainId": {"x"}, "limit": {"-1"}}, []int64{6, 5, 4, 3, 2, 1}},
+	}
+
+	for storeName, store := range testStores(t) {
+		handler := newTestHandler(store)
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				var resp botActionsResponse
+				if code := get(t, handler.GetBotActions, "/api/bot-actions", tt.query, &resp); code != http.StatusOK {
+					t.Fatalf("状态码 %d", code)
+				}
+				if got := ids(resp.Data); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("记录 %v, want %v", got, tt.want)
+				}
+				if resp.Count != len(tt.want) || resp.Total != int64(len(tt.want)) || resp.HasMore {
+					t.Errorf("count=%d total=%d hasMore=%v, want %d/%d/false", resp.Count, resp.Total, resp.HasMore, len(tt.want), len(tt.want))
+				}
+			})
+		}
+	}
+}
+
+func TestGetBotActionsLimitOffset(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []int64
+	}{
+		{"默认每页 10 条", url.Values{"type": {"COMPOUND"}}, []int64{6, 5, 1}},
+		{"limit", url.Values{"type": {"COMPOUND"}, "limit": {"2"}}, []int64{6, 5}},
+		{"offset", url.Values{"type": {"COMPOUND"}, "limit": {"2"}, "offset": {"2"}}, []int64{1}},
+		{"offset 超出范围", url.Values{"type": {"COMPOUND"}, "offset": {"3"}}, []int64{}},
+	}
+
+	for storeName, store := range testStores(t) {
+		handler := newTestHandler(store)
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				var resp botActionsResponse
+				if code := get(t, handler.GetBotActions, "/api/bot-actions", tt.query, &resp); code != http.StatusOK {
+					t.Fatalf("状态码 %d", code)
+				}
+				if got := ids(resp.Data); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("记录 %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestGetBotActionsInvalidParams(t *testing.T) {
+	handler := newTestHandler(db.NewMemoryBotActionStore())
+	for _, query := range []url.Values{
+		{"status": {"pending"}},
+		{"direction": {"AtoC"}},
+		{"txHash": {"0x1234"}},
+		{"from": {"yesterday"}},
+		{"to": {"2026-10-01"}},
+		{"minAmount": {"-1"}},
+		{"minAmount": {"1.5"}},
+		{"cursor": {"not-a-cursor"}},
+	} {
+		if code := get(t, handler.GetBotActions, "/api/bot-actions", query, nil); code != http.StatusBadRequest {
+			t.Errorf("%s: 状态码 %d, want 400", query.Encode(), code)
+		}
+	}
+}
+
+func TestGetBotActionsCursorPaging(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		pages [][]int64
+	}{
+		// 第二页在时间相同的记录 3 与 2 之间分页
+		{"每页 2 条", url.Values{"limit": {"2"}}, [][]int64{{6, 5}, {4, 3}, {2, 1}}},
+		{"每页 4 条", url.Values{"limit": {"4"}}, [][]int64{{6, 5, 4, 3}, {2, 1}}},
+		{"带过滤条件", url.Values{"limit": {"1"}, "pool": {"main"}, "type": {"REBALANCE"}}, [][]int64{{3}, {2}}},
+	}
+
+	for storeName, store := range testStores(t) {
+		handler := newTestHandler(store)
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				total := 0
+				for _, page := range tt.pages {
+					total += len(page)
+				}
+
+				query := tt.query
+				for i, want := range tt.pages {
+					var resp botActionsResponse
+					if code := get(t, handler.GetBotActions, "/api/bot-actions", query, &resp); code != http.StatusOK {
+						t.Fatalf("第 %d 页状态码 %d", i+1, code)
+					}
+					if got := ids(resp.Data); !reflect.DeepEqual(got, want) {
+						t.Fatalf("第 %d 页记录 %v, want %v", i+1, got, want)
+					}
+					if resp.Total != int64(total) {
+						t.Errorf("第 %d 页 total=%d, want %d", i+1, resp.Total, total)
+					}
+					last := i == len(tt.pages)-1
+					if resp.HasMore == last || (resp.NextCursor == "") != last {
+						t.Fatalf("第 %d 页 hasMore=%v nextCursor=%q", i+1, resp.HasMore, resp.NextCursor)
+					}
+
+					query = url.Values{}
+					for key, values := range tt.query {
+						query[key] = values
+					}
+					query.Set("cursor", resp.NextCursor)
+				}
+			})
+		}
+	}
+}
+
+type botStatsResponse struct {
+	Success        bool                  `json:"success"`
+	CompoundCount  int64                 `json:"compoundCount"`
+	RebalanceCount int64                 `json:"rebalanceCount"`
+	Summary        models.ActionStats    `json:"summary"`
+	Bucket         string                `json:"bucket"`
+	Buckets        []models.ActionStats  `json:"buckets"`
+	Pools          map[string]*PoolStats `json:"pools"`
+}
+
+func TestGetBotStatsSummary(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			var resp botStatsResponse
+			if code := get(t, newTestHandler(store).GetBotStats, "/api/bot-stats", url.Values{}, &resp); code != http.StatusOK {
+				t.Fatalf("状态码 %d", code)
+			}
+			if resp.CompoundCount != 3 || resp.RebalanceCount != 3 {
+				t.Errorf("compoundCount=%d rebalanceCount=%d, want 3/3", resp.CompoundCount, resp.RebalanceCount)
+			}
+
+			summary := resp.Summary
+			want := models.ActionStats{
+				Compound:        models.StatusCount{Success: 2, Failed: 1},
+				Rebalance:       models.StatusCount{Success: 2, Failed: 1},
+				Total:           6,
+				SuccessRate:     4.0 / 6.0,
+				GasUsed:         430000,
+				AvgGasUsed:      86000, // 记录 3 没有上链，不计入平均
+				GasCostWei:      "3500000000000000",
+				GasCostEth:      "0.0035",
+				VolumeAtoB:      "50",
+				VolumeBtoA:      "30", // 失败的记录 3 不计入
+				FeesCompoundedA: "110",
+				FeesCompoundedB: "220",
+			}
+			if !reflect.DeepEqual(summary, want) {
+				t.Errorf("summary = %+v\nwant %+v", summary, want)
+			}
+			if resp.Buckets != nil {
+				t.Errorf("未指定 bucket 时不应返回 buckets")
+			}
+
+			main, side := resp.Pools["main"], resp.Pools["side"]
+			if main == nil || side == nil {
+				t.Fatalf("pools = %+v", resp.Pools)
+			}
+			if main.CompoundCount != 2 || main.RebalanceCount != 2 || main.Chain != "local" || main.ChainID != 31337 {
+				t.Errorf("pools[main] = %+v", main)
+			}
+			if main.LatestAction == nil || main.LatestAction.TxHash != txHash(6) {
+				t.Errorf("pools[main].latestAction = %+v", main.LatestAction)
+			}
+			if side.CompoundCount != 1 || side.RebalanceCount != 1 || side.ChainID != 11155111 {
+				t.Errorf("pools[side] = %+v", side)
+			}
+		})
+	}
+}
+
+func TestGetBotStatsBuckets(t *testing.T) {
+	type bucket struct {
+		start time.Time
+		total int64
+	}
+	tests := []struct {
+		name    string
+		query   url.Values
+		width   time.Duration
+		buckets []bucket
+	}{
+		{"按小时", url.Values{"bucket": {"hour"}}, time.Hour, []bucket{
+			{seedBase, 3}, {seedBase.Add(time.Hour), 1}, {seedBase.Add(2 * time.Hour), 1}, {seedBase.Add(24 * time.Hour), 1},
+		}},
+		{"按天", url.Values{"bucket": {"day"}}, 24 * time.Hour, []bucket{
+			{seedBase, 5}, {seedBase.Add(24 * time.Hour), 1},
+		}},
+		{"15 分钟并过滤池", url.Values{"bucket": {"15m"}, "pool": {"main"}}, 15 * time.Minute, []bucket{
+			{seedBase, 1}, {seedBase.Add(15 * time.Minute), 2}, {seedBase.Add(24 * time.Hour), 1},
+		}},
+		{"时间范围内按周", url.Values{
+			"bucket": {"week"},
+			"from":   {seedBase.Add(time.Hour).Format(time.RFC3339)},
+			"to":     {seedBase.Add(24 * time.Hour).Format(time.RFC3339)},
+		}, 7 * 24 * time.Hour, []bucket{
+			// 按 unix 时间对齐，1970-01-01 是周四
+			{time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), 2},
+		}},
+	}
+
+	for storeName, store := range testStores(t) {
+		handler := newTestHandler(store)
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				var resp botStatsResponse
+				if code := get(t, handler.GetBotStats, "/api/bot-stats", tt.query, &resp); code != http.StatusOK {
+					t.Fatalf("状态码 %d", code)
+				}
+				if resp.Bucket != tt.query.Get("bucket") {
+					t.Errorf("bucket = %q", resp.Bucket)
+				}
+				if len(resp.Buckets) != len(tt.buckets) {
+					t.Fatalf("返回 %d 个时间段, want %d: %+v", len(resp.Buckets), len(tt.buckets), resp.Buckets)
+				}
+				var sum int64
+				for i, want := range tt.buckets {
+					got := resp.Buckets[i]
+					if got.Start == nil || got.End == nil || !got.Start.Equal(want.start) || !got.End.Equal(want.start.Add(tt.width)) {
+						t.Errorf("时间段 %d = [%v, %v), want 从 %v 开始", i, got.Start, got.End, want.start)
+					}
+					if got.Total != want.total {
+						t.Errorf("时间段 %d total=%d, want %d", i, got.Total, want.total)
+					}
+					sum += got.Total
+				}
+				if resp.Summary.Total != sum {
+					t.Errorf("summary.total=%d, 各时间段合计 %d", resp.Summary.Total, sum)
+				}
+				if from := tt.query.Get("from"); from != "" && (resp.Summary.Start == nil || resp.Summary.Start.Format(time.RFC3339) != from) {
+					t.Errorf("summary.start = %v, want %s", resp.Summary.Start, from)
+				}
+			})
+		}
+	}
+}
+
+func TestGetBotStatsBucketContents(t *testing.T) {
+	for storeName, store := range testStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			var resp botStatsResponse
+			query := url.Values{"bucket": {"hour"}, "pool": {"main"}}
+			if code := get(t, newTestHandler(store).GetBotStats, "/api/bot-stats", query, &resp); code != http.StatusOK {
+				t.Fatalf("状态码 %d", code)
+			}
+			if resp.Pools != nil {
+				t.Errorf("指定池时不应返回 pools")
+			}
+			if len(resp.Buckets) != 2 {
+				t.Fatalf("buckets = %+v", resp.Buckets)
+			}
+
+			first := resp.Buckets[0]
+			if first.Compound != (models.StatusCount{Success: 1}) || first.Rebalance != (models.StatusCount{Success: 1, Failed: 1}) {
+				t.Errorf("第一个时间段 compound=%+v rebalance=%+v", first.Compound, first.Rebalance)
+			}
+			if first.GasUsed != 180000 || first.AvgGasUsed != 90000 || first.GasCostWei != "1500000000000000" || first.GasCostEth != "0.0015" {
+				t.Errorf("第一个时间段 gas: %+v", first)
+			}
+			if first.VolumeAtoB != "50" || first.VolumeBtoA != "0" || first.FeesCompoundedA != "100" || first.FeesCompoundedB != "200" {
+				t.Errorf("第一个时间段数量: %+v", first)
+			}
+			if first.SuccessRate != 2.0/3.0 {
+				t.Errorf("第一个时间段 successRate=%v", first.SuccessRate)
+			}
+		})
+	}
+}
+
+func TestGetBotStatsInvalidBucket(t *testing.T) {
+	handler := newTestHandler(db.NewMemoryBotActionStore())
+	for _, bucket := range []string{"month", "30s", "90s", "-1h"} {
+		if code := get(t, handler.GetBotStats, "/api/bot-stats", url.Values{"bucket": {bucket}}, nil); code != http.StatusBadRequest {
+			t.Errorf("bucket=%s: 状态码 %d, want 400", bucket, code)
+		}
+	}
+}
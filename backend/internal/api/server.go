@@ -4,13 +4,20 @@ import (
 	"context"
 	"fmt"
 	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/health"
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/scheduler"
+	"mini-amm-bot/internal/services"
 	"mini-amm-bot/internal/util"
+	"net"
 	"net/http"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
+var logger = logging.Component("api")
+
 type Server struct {
 	httpServer *http.Server
 	handler    *Handler
@@ -34,36 +41,52 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func NewServer(port int, repo *db.BotActionRepository, config *util.Config) *Server {
-	handler := NewHandler(repo, config)
+func NewServer(port int, repo db.BotActionStore, snapshots db.PoolSnapshotStore, bus *events.Bus, config *util.Config, preflight *services.PreflightReport, readiness *health.Checker, sched *scheduler.Scheduler, compoundServices []*services.CompoundService, rebalanceServices []*services.RebalanceService) *Server {
+	handler := NewHandler(repo, snapshots, bus, config, preflight, readiness, sched, compoundServices, rebalanceServices)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/bot-actions", handler.GetBotActions)
 	mux.HandleFunc("/api/bot-stats", handler.GetBotStats)
 	mux.HandleFunc("/api/bot-config", handler.GetBotConfig)
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
-	})
+	mux.HandleFunc("/api/schedules", handler.GetSchedules)
+	mux.HandleFunc("/api/compound-interval", handler.GetCompoundInterval)
+	mux.HandleFunc("/api/fee-remainders", handler.GetFeeRemainders)
+	mux.HandleFunc("/api/export/bot-actions.csv", handler.ExportBotActions)
+	mux.HandleFunc("/api/export/bot-actions.ndjson", handler.ExportBotActions)
+	mux.HandleFunc("/api/export/pool-snapshots.csv", handler.ExportPoolSnapshots)
+	mux.HandleFunc("/api/export/pool-snapshots.ndjson", handler.ExportPoolSnapshots)
+	mux.HandleFunc("/health", handler.GetHealth)
+	mux.HandleFunc("/api/stream", handler.Stream)
+	mux.HandleFunc("/api/ws", handler.StreamWebSocket)
+	mux.HandleFunc("/healthz", handler.GetLiveness)
+	mux.HandleFunc("/readyz", handler.GetReadiness)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/admin/log-levels", handler.requireAdmin(handler.LogLevels))
+	mux.HandleFunc("/admin/pause", handler.requireAdmin(handler.Pause))
 
 	// 包装 mux 以添加 CORS 中间件
 	corsHandler := corsMiddleware(mux)
 
+	// Shutdown 不会中断仍在推送的事件流，关闭时取消所有请求的 context 让它们结束
+	baseCtx, cancel := context.WithCancel(context.Background())
+	httpServer := &http.Server{
+		Addr:         fmt.Sprintf(":%d", port),
+		Handler:      corsHandler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return baseCtx },
+	}
+	httpServer.RegisterOnShutdown(cancel)
+
 	return &Server{
-		httpServer: &http.Server{
-			Addr:         fmt.Sprintf(":%d", port),
-			Handler:      corsHandler,
-			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
-			IdleTimeout:  60 * time.Second,
-		},
-		handler: handler,
+		httpServer: httpServer,
+		handler:    handler,
 	}
 }
 
 func (s *Server) Start() error {
-	log.Infof("API 服务器启动在 %s", s.httpServer.Addr)
+	logger.Infof("API 服务器启动在 %s", s.httpServer.Addr)
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start API server: %w", err)
 	}
@@ -71,6 +94,6 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Info("正在关闭 API 服务器...")
+	logger.Info("正在关闭 API 服务器...")
 	return s.httpServer.Shutdown(ctx)
 }
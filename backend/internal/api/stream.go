@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"mini-amm-bot/internal/events"
+)
+
+const (
+	streamBuffer    = 256              // 每个连接缓冲的事件数，写不过来时断开，由客户端带上最后的事件 ID 重连
+	streamKeepAlive = 15 * time.Second // SSE 注释行/WebSocket ping 的间隔，避免代理断开空闲连接
+	wsPongWait      = 2 * streamKeepAlive
+	wsWriteTimeout  = 10 * time.Second
+)
+
+// upgrader 与 corsMiddleware 一致允许所有来源
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Stream 以 Server-Sent Events 推送实时事件：新的操作记录、交易状态、池状态和服务暂停/恢复。
+// 支持 ?types=bot_action,tx_confirmed&pool=main 过滤；重连时浏览器自动带上 Last-Event-ID，从最近的历史事件中补发
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+		return
+	}
+
+	filter, after, err := parseEventFilter(r.URL.Query(), r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warnf("取消事件流的写超时失败: %v", err)
+	}
+
+	sub := h.bus.Subscribe(filter, after, streamBuffer)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	controller.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Errorf("序列化事件失败: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// StreamWebSocket 与 Stream 相同的事件，以 WebSocket 文本消息（每条一个 JSON 事件）推送；
+// 没有 Last-Event-ID 头，重连时用 ?lastEventId= 补发
+func (h *Handler) StreamWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, after, err := parseEventFilter(r.URL.Query(), "")
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade 已经写出错误响应
+		return
+	}
+	defer conn.Close()
+
+	sub := h.bus.Subscribe(filter, after, streamBuffer)
+	defer sub.Close()
+
+	// 只读取控制帧：收到 pong 延长读超时，客户端断开或超时后结束
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamKeepAlive)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-r.Context().Done():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"), time.Now().Add(wsWriteTimeout))
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber too slow"), time.Now().Add(wsWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseEventFilter 解析 types、pool 过滤参数，以及补发起点（lastEventId 参数或 Last-Event-ID 头）
+func parseEventFilter(query url.Values, lastEventID string) (events.Filter, uint64, error) {
+	filter := events.Filter{Pool: query.Get("pool")}
+
+	if typesStr := query.Get("types"); typesStr != "" {
+		for _, raw := range strings.Split(typesStr, ",") {
+			eventType := events.Type(strings.TrimSpace(raw))
+			if !knownEventType(eventType) {
+				return filter, 0, fmt.Errorf("invalid event type %q: want one of %s", raw, knownEventTypes())
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	if raw := query.Get("lastEventId"); raw != "" {
+		lastEventID = raw
+	}
+	var after uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return filter, 0, fmt.Errorf("invalid last event id %q", lastEventID)
+		}
+		after = parsed
+	}
+	return filter, after, nil
+}
+
+func knownEventType(eventType events.Type) bool {
+	for _, known := range events.Types {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func knownEventTypes() string {
+	names := make([]string, len(events.Types))
+	for i, eventType := range events.Types {
+		names[i] = string(eventType)
+	}
+	return strings.Join(names, ", ")
+}
@@ -1,29 +1,119 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
 	"mini-amm-bot/internal/models"
+	"mini-amm-bot/internal/tracing"
 )
 
+// botActionColumns SELECT 语句使用的列顺序，必须与 scanBotAction 保持一致
+const botActionColumns = `id, pool, chain_id, timestamp, action_type, amount_a, amount_b, tx_hash, direction, status, gas_used, created_at, trace_id, fee_value_wei, est_gas_cost_wei, gas_cost_wei,
+	lp_minted, fee_remainder_a, fee_remainder_b, block_number, amount_in, amount_out, token_in, token_out, effective_price,
+	reserve_a_before, reserve_b_before, reserve_a_after, reserve_b_after, reserves_approximate`
+
+// BotActionStore 操作记录的存储，服务和 API 只依赖该接口
+type BotActionStore interface {
+	Create(ctx context.Context, action *models.BotAction) error
+	List(filter QueryFilter) ([]models.BotAction, error)
+	Count(filter QueryFilter) (int64, error)
+	Aggregate(ctx context.Context, filter QueryFilter, bucket time.Duration) ([]models.ActionStats, error)
+	Stream(ctx context.Context, filter QueryFilter, fn func(action *models.BotAction) error) error
+	GetByTxHash(txHash string) (*models.BotAction, error)
+	ListSuccessfulSince(ctx context.Context, pool string, actionType models.ActionType, since time.Time) ([]models.BotAction, error)
+	CountByType(actionType models.ActionType, pool *string) (int64, error)
+	GetLatestAction(pool *string) (*models.BotAction, error)
+}
+
+// BotActionRepository 基于 SQL 的 BotActionStore，PostgreSQL 与 SQLite 共用同一套查询
 type BotActionRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
 }
 
 func NewBotActionRepository(db *sql.DB) *BotActionRepository {
-	return &BotActionRepository{db: db}
+	return &BotActionRepository{db: db, dialect: DialectPostgres}
+}
+
+func NewSQLiteBotActionRepository(db *sql.DB) *BotActionRepository {
+	return &BotActionRepository{db: db, dialect: DialectSQLite}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *BotActionRepository) Create(action *models.BotAction) error {
+func scanBotAction(row rowScanner, action *models.BotAction) error {
+	return row.Scan(
+		&action.ID,
+		&action.Pool,
+		&action.ChainID,
+		&action.Timestamp,
+		&action.ActionType,
+		&action.AmountA,
+		&action.AmountB,
+		&action.TxHash,
+		&action.Direction,
+		&action.Status,
+		&action.GasUsed,
+		&action.CreatedAt,
+		&action.TraceID,
+		&action.FeeValueWei,
+		&action.EstimatedGasCostWei,
+		&action.GasCostWei,
+		&action.LPMinted,
+		&action.FeeRemainderA,
+		&action.FeeRemainderB,
+		&action.BlockNumber,
+		&action.AmountIn,
+		&action.AmountOut,
+		&action.TokenIn,
+		&action.TokenOut,
+		&action.EffectivePrice,
+		&action.ReserveABefore,
+		&action.ReserveBBefore,
+		&action.ReserveAAfter,
+		&action.ReserveBAfter,
+		&action.ReservesApproximate,
+	)
+}
+
+// Create 保存一条操作记录，ctx 中有进行中的 trace 时记录 span 并把 trace ID 写入 trace_id 列
+func (r *BotActionRepository) Create(ctx context.Context, action *models.BotAction) (err error) {
+	ctx, span := tracing.Start(ctx, "BotActionRepository.Create",
+		attribute.String("db.system", r.dialect.dbSystem()),
+		attribute.String("db.operation", "INSERT"),
+		attribute.String("db.sql.table", "bot_actions"),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if action.TraceID == "" {
+		action.TraceID = tracing.TraceID(ctx)
+	}
+
 	query := `
-		INSERT INTO bot_actions (timestamp, action_type, amount_a, amount_b, tx_hash, direction, status, gas_used)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO bot_actions (pool, chain_id, timestamp, action_type, amount_a, amount_b, tx_hash, direction, status, gas_used, trace_id,
+			fee_value_wei, est_gas_cost_wei, gas_cost_wei, lp_minted, fee_remainder_a, fee_remainder_b,
+			block_number, amount_in, amount_out, token_in, token_out, effective_price,
+			reserve_a_before, reserve_b_before, reserve_a_after, reserve_b_after, reserves_approximate)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
 		RETURNING id, created_at
 	`
 
-	err := r.db.QueryRow(
+	err = r.db.QueryRowContext(
+		ctx,
 		query,
-		action.Timestamp,
+		action.Pool,
+		action.ChainID,
+		r.dialect.timeArg(action.Timestamp),
 		action.ActionType,
 		action.AmountA,
 		action.AmountB,
@@ -31,6 +121,24 @@ func (r *BotActionRepository) Create(action *models.BotAction) error {
 		action.Direction,
 		action.Status,
 		action.GasUsed,
+		action.TraceID,
+		action.FeeValueWei,
+		action.EstimatedGasCostWei,
+		action.GasCostWei,
+		action.LPMinted,
+		action.FeeRemainderA,
+		action.FeeRemainderB,
+		action.BlockNumber,
+		action.AmountIn,
+		action.AmountOut,
+		action.TokenIn,
+		action.TokenOut,
+		action.EffectivePrice,
+		action.ReserveABefore,
+		action.ReserveBBefore,
+		action.ReserveAAfter,
+		action.ReserveBAfter,
+		action.ReservesApproximate,
 	).Scan(&action.ID, &action.CreatedAt)
 
 	if err != nil {
@@ -41,37 +149,98 @@ func (r *BotActionRepository) Create(action *models.BotAction) error {
 }
 
 type QueryFilter struct {
+	Pool       *string
+	ChainID    *int64
 	ActionType *models.ActionType
+	Status     *string
+	Direction  *string
+	TxHash     *string    // 小写的 0x 开头交易哈希
+	From       *time.Time // timestamp >= From
+	To         *time.Time // timestamp < To
+	MinAmount  *big.Int   // amount_a 或 amount_b 不小于该值（最小单位）
+	After      *Cursor    // 键集分页：只返回排在该游标之后的记录
 	Limit      int
 	Offset     int
 }
 
-func (r *BotActionRepository) List(filter QueryFilter) ([]models.BotAction, error) {
-	query := `
-		SELECT id, timestamp, action_type, amount_a, amount_b, tx_hash, direction, status, gas_used, created_at
-		FROM bot_actions
-	`
-
+// where 按过滤条件生成 WHERE 子句（不含游标）及参数
+func (r *BotActionRepository) where(filter QueryFilter) (string, []interface{}) {
+	conditions := []string{}
 	args := []interface{}{}
-	argIndex := 1
+	add := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
 
+	if filter.Pool != nil {
+		add("pool = %s", *filter.Pool)
+	}
+	if filter.ChainID != nil {
+		add("chain_id = %s", *filter.ChainID)
+	}
 	if filter.ActionType != nil {
-		query += fmt.Sprintf(" WHERE action_type = $%d", argIndex)
-		args = append(args, *filter.ActionType)
-		argIndex++
+		add("action_type = %s", *filter.ActionType)
 	}
+	if filter.Status != nil {
+		add("status = %s", *filter.Status)
+	}
+	if filter.Direction != nil {
+		add("direction = %s", *filter.Direction)
+	}
+	if filter.TxHash != nil {
+		add("tx_hash = %s", *filter.TxHash)
+	}
+	if filter.From != nil {
+		add("timestamp >= %s", r.dialect.timeArg(*filter.From))
+	}
+	if filter.To != nil {
+		add("timestamp < %s", r.dialect.timeArg(*filter.To))
+	}
+	if filter.MinAmount != nil {
+		// 数量以十进制字符串保存，比较前转换为数值；空字符串视为 NULL
+		add("(CAST(NULLIF(amount_a, '') AS NUMERIC) >= %s OR CAST(NULLIF(amount_b, '') AS NUMERIC) >= %s)",
+			filter.MinAmount.String(), filter.MinAmount.String())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
 
-	query += " ORDER BY timestamp DESC"
+// List 按 (timestamp, id) 倒序返回满足条件的记录，After 非空时从游标之后继续
+func (r *BotActionRepository) List(filter QueryFilter) ([]models.BotAction, error) {
+	where, args := r.where(filter)
+	if filter.After != nil {
+		args = append(args, r.dialect.timeArg(filter.After.Timestamp), filter.After.ID)
+		condition := fmt.Sprintf("(timestamp, id) < ($%d, $%d)", len(args)-1, len(args))
+		if where == "" {
+			where = " WHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+	}
+
+	query := `
+		SELECT ` + botActionColumns + `
+		FROM bot_actions` + where + `
+		ORDER BY timestamp DESC, id DESC`
 
 	if filter.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT $%d", argIndex)
 		args = append(args, filter.Limit)
-		argIndex++
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	} else if filter.Offset > 0 && r.dialect == DialectSQLite {
+		// SQLite 的 OFFSET 必须跟在 LIMIT 之后，-1 表示不限制
+		query += " LIMIT -1"
 	}
 
 	if filter.Offset > 0 {
-		query += fmt.Sprintf(" OFFSET $%d", argIndex)
 		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
 	}
 
 	rows, err := r.db.Query(query, args...)
@@ -83,19 +252,7 @@ func (r *BotActionRepository) List(filter QueryFilter) ([]models.BotAction, erro
 	actions := []models.BotAction{}
 	for rows.Next() {
 		var action models.BotAction
-		err := rows.Scan(
-			&action.ID,
-			&action.Timestamp,
-			&action.ActionType,
-			&action.AmountA,
-			&action.AmountB,
-			&action.TxHash,
-			&action.Direction,
-			&action.Status,
-			&action.GasUsed,
-			&action.CreatedAt,
-		)
-		if err != nil {
+		if err := scanBotAction(rows, &action); err != nil {
 			return nil, fmt.Errorf("failed to scan bot action: %w", err)
 		}
 		actions = append(actions, action)
@@ -108,26 +265,113 @@ func (r *BotActionRepository) List(filter QueryFilter) ([]models.BotAction, erro
 	return actions, nil
 }
 
+// Stream 按 (timestamp, id) 升序逐行读取满足条件的记录并交给 fn，不把结果集读入内存；忽略游标与分页参数
+func (r *BotActionRepository) Stream(ctx context.Context, filter QueryFilter, fn func(action *models.BotAction) error) error {
+	where, args := r.where(filter)
+	query := `
+		SELECT ` + botActionColumns + `
+		FROM bot_actions` + where + `
+		ORDER BY timestamp ASC, id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query bot actions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var action models.BotAction
+		if err := scanBotAction(rows, &action); err != nil {
+			return fmt.Errorf("failed to scan bot action: %w", err)
+		}
+		if err := fn(&action); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	return nil
+}
+
+// Count 满足过滤条件的记录总数，忽略游标与分页参数
+func (r *BotActionRepository) Count(filter QueryFilter) (int64, error) {
+	where, args := r.where(filter)
+
+	var count int64
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM bot_actions`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count bot actions: %w", err)
+	}
+	return count, nil
+}
+
+// Aggregate 用 SQL 按时间段汇总满足条件的记录，时间段按 unix 时间对齐（天按 UTC 零点），只返回有记录的时间段；
+// bucket 为 0 时汇总整个范围，总是返回一个结果。忽略游标与分页参数
+func (r *BotActionRepository) Aggregate(ctx context.Context, filter QueryFilter, bucket time.Duration) ([]models.ActionStats, error) {
+	where, args := r.where(filter)
+
+	bucketExpr := "0"
+	if bucket > 0 {
+		args = append(args, int64(bucket/time.Second))
+		bucketExpr = fmt.Sprintf("(%s / $%d) * $%d", r.dialect.unixSeconds("timestamp"), len(args), len(args))
+	}
+
+	query := `
+		SELECT ` + bucketExpr + ` AS bucket, action_type, status,
+			COUNT(*),
+			COALESCE(SUM(gas_used), 0),
+			SUM(CASE WHEN gas_used > 0 THEN 1 ELSE 0 END),
+			` + r.dialect.sumAmount("gas_cost_wei") + `,
+			` + r.dialect.sumAmount("CASE WHEN status = 'success' AND direction = 'AtoB' THEN amount_in END") + `,
+			` + r.dialect.sumAmount("CASE WHEN status = 'success' AND direction = 'BtoA' THEN amount_in END") + `,
+			` + r.dialect.sumAmount("CASE WHEN status = 'success' AND action_type = 'COMPOUND' THEN amount_a END") + `,
+			` + r.dialect.sumAmount("CASE WHEN status = 'success' AND action_type = 'COMPOUND' THEN amount_b END") + `
+		FROM bot_actions` + where + `
+		GROUP BY bucket, action_type, status`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate bot actions: %w", err)
+	}
+	defer rows.Close()
+
+	accumulator := newStatsAccumulator()
+	for rows.Next() {
+		var row statsRow
+		if err := rows.Scan(
+			&row.bucket,
+			&row.actionType,
+			&row.status,
+			&row.count,
+			&row.gasUsed,
+			&row.txCount,
+			&row.gasCostWei,
+			&row.volumeAtoB,
+			&row.volumeBtoA,
+			&row.feesCompoundedA,
+			&row.feesCompoundedB,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan bot action stats: %w", err)
+		}
+		accumulator.add(row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return accumulator.result(bucket), nil
+}
+
 func (r *BotActionRepository) GetByTxHash(txHash string) (*models.BotAction, error) {
 	query := `
-		SELECT id, timestamp, action_type, amount_a, amount_b, tx_hash, direction, status, gas_used, created_at
+		SELECT ` + botActionColumns + `
 		FROM bot_actions
 		WHERE tx_hash = $1
 	`
 
 	var action models.BotAction
-	err := r.db.QueryRow(query, txHash).Scan(
-		&action.ID,
-		&action.Timestamp,
-		&action.ActionType,
-		&action.AmountA,
-		&action.AmountB,
-		&action.TxHash,
-		&action.Direction,
-		&action.Status,
-		&action.GasUsed,
-		&action.CreatedAt,
-	)
+	err := scanBotAction(r.db.QueryRow(query, txHash), &action)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -139,11 +383,48 @@ func (r *BotActionRepository) GetByTxHash(txHash string) (*models.BotAction, err
 	return &action, nil
 }
 
-func (r *BotActionRepository) CountByType(actionType models.ActionType) (int64, error) {
+// ListSuccessfulSince 按时间升序返回某池 since 之后成功的某类操作，用于估算手续费累积速度等统计
+func (r *BotActionRepository) ListSuccessfulSince(ctx context.Context, pool string, actionType models.ActionType, since time.Time) ([]models.BotAction, error) {
+	query := `
+		SELECT ` + botActionColumns + `
+		FROM bot_actions
+		WHERE pool = $1 AND action_type = $2 AND status = 'success' AND timestamp >= $3
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pool, actionType, r.dialect.timeArg(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bot actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []models.BotAction{}
+	for rows.Next() {
+		var action models.BotAction
+		if err := scanBotAction(rows, &action); err != nil {
+			return nil, fmt.Errorf("failed to scan bot action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return actions, nil
+}
+
+// CountByType 统计某类操作的次数，pool 为 nil 时统计所有池
+func (r *BotActionRepository) CountByType(actionType models.ActionType, pool *string) (int64, error) {
 	query := `SELECT COUNT(*) FROM bot_actions WHERE action_type = $1`
+	args := []interface{}{actionType}
+	if pool != nil {
+		query += ` AND pool = $2`
+		args = append(args, *pool)
+	}
 
 	var count int64
-	err := r.db.QueryRow(query, actionType).Scan(&count)
+	err := r.db.QueryRow(query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count bot actions: %w", err)
 	}
@@ -151,27 +432,21 @@ func (r *BotActionRepository) CountByType(actionType models.ActionType) (int64,
 	return count, nil
 }
 
-func (r *BotActionRepository) GetLatestAction() (*models.BotAction, error) {
+// GetLatestAction 返回最近一次操作，pool 为 nil 时在所有池中查找
+func (r *BotActionRepository) GetLatestAction(pool *string) (*models.BotAction, error) {
 	query := `
-		SELECT id, timestamp, action_type, amount_a, amount_b, tx_hash, direction, status, gas_used, created_at
+		SELECT ` + botActionColumns + `
 		FROM bot_actions
-		ORDER BY timestamp DESC
-		LIMIT 1
 	`
+	args := []interface{}{}
+	if pool != nil {
+		query += ` WHERE pool = $1`
+		args = append(args, *pool)
+	}
+	query += ` ORDER BY timestamp DESC LIMIT 1`
 
 	var action models.BotAction
-	err := r.db.QueryRow(query).Scan(
-		&action.ID,
-		&action.Timestamp,
-		&action.ActionType,
-		&action.AmountA,
-		&action.AmountB,
-		&action.TxHash,
-		&action.Direction,
-		&action.Status,
-		&action.GasUsed,
-		&action.CreatedAt,
-	)
+	err := scanBotAction(r.db.QueryRow(query, args...), &action)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"mini-amm-bot/internal/models"
+)
+
+// Cursor 键集分页的位置，记录上一页最后一条记录的 (timestamp, id)
+type Cursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        int64     `json:"id"`
+}
+
+// CursorAfter 以 action 为上一页最后一条记录的游标
+func CursorAfter(action *models.BotAction) *Cursor {
+	return &Cursor{Timestamp: action.Timestamp, ID: action.ID}
+}
+
+// Encode 编码为 URL 安全的不透明字符串
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor 解析 Encode 生成的游标
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID <= 0 || cursor.Timestamp.IsZero() {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"mini-amm-bot/internal/models"
+)
+
+// MemoryBotActionStore 保存在进程内存中的 BotActionStore，用于本地开发和测试，重启后数据丢失
+type MemoryBotActionStore struct {
+	mu      sync.RWMutex
+	actions []models.BotAction
+	nextID  int64
+}
+
+func NewMemoryBotActionStore() *MemoryBotActionStore {
+	return &MemoryBotActionStore{nextID: 1}
+}
+
+// Ping 内存存储始终可用
+func (m *MemoryBotActionStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryBotActionStore) Close() error {
+	return nil
+}
+
+func (m *MemoryBotActionStore) Create(ctx context.Context, action *models.BotAction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	action.ID = m.nextID
+	action.CreatedAt = time.Now()
+	m.nextID++
+	m.actions = append(m.actions, cloneBotAction(action))
+	return nil
+}
+
+func (m *MemoryBotActionStore) List(filter QueryFilter) ([]models.BotAction, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return matchesFilter(action, filter) && (filter.After == nil || before(action, filter.After))
+	})
+	sortByTimestamp(actions, true)
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(actions) {
+			return []models.BotAction{}, nil
+		}
+		actions = actions[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(actions) {
+		actions = actions[:filter.Limit]
+	}
+	return actions, nil
+}
+
+func (m *MemoryBotActionStore) Count(filter QueryFilter) (int64, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return matchesFilter(action, filter)
+	})
+	return int64(len(actions)), nil
+}
+
+func (m *MemoryBotActionStore) Stream(ctx context.Context, filter QueryFilter, fn func(action *models.BotAction) error) error {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return matchesFilter(action, filter)
+	})
+	sortByTimestamp(actions, false)
+	for i := range actions {
+		if err := fn(&actions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryBotActionStore) Aggregate(ctx context.Context, filter QueryFilter, bucket time.Duration) ([]models.ActionStats, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return matchesFilter(action, filter)
+	})
+
+	accumulator := newStatsAccumulator()
+	for i := range actions {
+		action := &actions[i]
+		row := statsRow{
+			actionType: action.ActionType,
+			status:     action.Status,
+			count:      1,
+			gasUsed:    int64(action.GasUsed),
+			gasCostWei: action.GasCostWei,
+		}
+		if bucket > 0 {
+			seconds := int64(bucket / time.Second)
+			row.bucket = action.Timestamp.Unix() / seconds * seconds
+		}
+		if action.GasUsed > 0 {
+			row.txCount = 1
+		}
+		if action.Status == "success" {
+			switch {
+			case action.ActionType == models.ActionTypeCompound:
+				row.feesCompoundedA, row.feesCompoundedB = action.AmountA, action.AmountB
+			case action.Direction != nil && *action.Direction == "AtoB":
+				row.volumeAtoB = action.AmountIn
+			case action.Direction != nil && *action.Direction == "BtoA":
+				row.volumeBtoA = action.AmountIn
+			}
+		}
+		accumulator.add(row)
+	}
+	return accumulator.result(bucket), nil
+}
+
+func (m *MemoryBotActionStore) GetByTxHash(txHash string) (*models.BotAction, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return action.TxHash == txHash
+	})
+	if len(actions) == 0 {
+		return nil, nil
+	}
+	return &actions[0], nil
+}
+
+func (m *MemoryBotActionStore) ListSuccessfulSince(ctx context.Context, pool string, actionType models.ActionType, since time.Time) ([]models.BotAction, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return action.Pool == pool && action.ActionType == actionType &&
+			action.Status == "success" && !action.Timestamp.Before(since)
+	})
+	sortByTimestamp(actions, false)
+	return actions, nil
+}
+
+func (m *MemoryBotActionStore) CountByType(actionType models.ActionType, pool *string) (int64, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return action.ActionType == actionType && (pool == nil || action.Pool == *pool)
+	})
+	return int64(len(actions)), nil
+}
+
+func (m *MemoryBotActionStore) GetLatestAction(pool *string) (*models.BotAction, error) {
+	actions := m.filter(func(action *models.BotAction) bool {
+		return pool == nil || action.Pool == *pool
+	})
+	if len(actions) == 0 {
+		return nil, nil
+	}
+	sortByTimestamp(actions, true)
+	return &actions[0], nil
+}
+
+// filter 返回满足条件的记录副本，调用方可以随意修改
+func (m *MemoryBotActionStore) filter(match func(action *models.BotAction) bool) []models.BotAction {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	actions := []models.BotAction{}
+	for i := range m.actions {
+		if match(&m.actions[i]) {
+			actions = append(actions, cloneBotAction(&m.actions[i]))
+		}
+	}
+	return actions
+}
+
+// matchesFilter 与 BotActionRepository.where 相同的过滤条件（不含游标）
+func matchesFilter(action *models.BotAction, filter QueryFilter) bool {
+	switch {
+	case filter.Pool != nil && action.Pool != *filter.Pool,
+		filter.ChainID != nil && action.ChainID != *filter.ChainID,
+		filter.ActionType != nil && action.ActionType != *filter.ActionType,
+		filter.Status != nil && action.Status != *filter.Status,
+		filter.Direction != nil && (action.Direction == nil || *action.Direction != *filter.Direction),
+		filter.TxHash != nil && action.TxHash != *filter.TxHash,
+		filter.From != nil && action.Timestamp.Before(*filter.From),
+		filter.To != nil && !action.Timestamp.Before(*filter.To):
+		return false
+	}
+	if filter.MinAmount != nil {
+		return atLeast(action.AmountA, filter.MinAmount) || atLeast(action.AmountB, filter.MinAmount)
+	}
+	return true
+}
+
+func atLeast(amount string, threshold *big.Int) bool {
+	value, ok := new(big.Int).SetString(amount, 10)
+	return ok && value.Cmp(threshold) >= 0
+}
+
+// before action 在 (timestamp, id) 倒序中是否排在游标之后
+func before(action *models.BotAction, cursor *Cursor) bool {
+	if action.Timestamp.Equal(cursor.Timestamp) {
+		return action.ID < cursor.ID
+	}
+	return action.Timestamp.Before(cursor.Timestamp)
+}
+
+// sortByTimestamp 按 (timestamp, id) 排序
+func sortByTimestamp(actions []models.BotAction, desc bool) {
+	sort.Slice(actions, func(i, j int) bool {
+		a, b := &actions[i], &actions[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp) != desc
+		}
+		return (a.ID < b.ID) != desc
+	})
+}
+
+// cloneBotAction 复制记录，避免调用方通过 Direction 指针修改存储中的数据
+func cloneBotAction(action *models.BotAction) models.BotAction {
+	clone := *action
+	if action.Direction != nil {
+		direction := *action.Direction
+		clone.Direction = &direction
+	}
+	return clone
+}
+
+// MemoryPoolSnapshotStore 保存在进程内存中的 PoolSnapshotStore
+type MemoryPoolSnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots []models.PoolSnapshot
+	nextID    int64
+}
+
+func NewMemoryPoolSnapshotStore() *MemoryPoolSnapshotStore {
+	return &MemoryPoolSnapshotStore{nextID: 1}
+}
+
+func (m *MemoryPoolSnapshotStore) Create(ctx context.Context, snapshot *models.PoolSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot.ID = m.nextID
+	m.nextID++
+	m.snapshots = append(m.snapshots, *snapshot)
+	return nil
+}
+
+func (m *MemoryPoolSnapshotStore) Stream(ctx context.Context, filter SnapshotFilter, fn func(snapshot *models.PoolSnapshot) error) error {
+	m.mu.RLock()
+	snapshots := []models.PoolSnapshot{}
+	for _, snapshot := range m.snapshots {
+		if (filter.Pool == nil || snapshot.Pool == *filter.Pool) &&
+			(filter.From == nil || !snapshot.Timestamp.Before(*filter.From)) &&
+			(filter.To == nil || snapshot.Timestamp.Before(*filter.To)) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Timestamp.Before(snapshots[j].Timestamp) })
+	for i := range snapshots {
+		if err := fn(&snapshots[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,403 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// Dialect SQL 方言，决定使用 migrations 下的哪个目录以及迁移时如何加锁
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// migrationLockKey 迁移使用的 PostgreSQL advisory lock，多个实例同时启动时只有一个执行迁移
+const migrationLockKey int64 = 0x6d696e69616d6d // "miniamm"
+
+// squashedBaseline 各方言第一个迁移已包含到哪个版本为止的表结构。
+// SQLite 的 0001 直接建立与 PostgreSQL 0001–0007 相同的表结构，所以 SQLite 上不存在 1–6 对应的中间状态，
+// 只能回滚到该版本或整个删除数据库
+var squashedBaseline = map[Dialect]int64{
+	DialectSQLite: 7,
+}
+
+// Migration 一个版本的迁移，文件名格式为 <版本>_<名称>.up.sql / <版本>_<名称>.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 迁移在数据库中的状态，AppliedAt 为 nil 表示尚未执行
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrations 返回某个方言内嵌的全部迁移，按版本升序
+func Migrations(dialect Dialect) ([]Migration, error) {
+	dir := path.Join("migrations", string(dialect))
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q: want <version>_<name>.up.sql or .down.sql", file)
+		}
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q: missing name", file)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %q", file)
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join(dir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migration version %d has conflicting names %q and %q", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator 在 schema_migrations 表中记录已执行的版本，按版本顺序执行或回滚迁移
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的数量
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if newest := maxVersion(applied); newest > m.latest() {
+			logger.Warnf("数据库迁移版本 %d 比程序内置的最新版本 %d 新，可能正在运行旧版本程序", newest, m.latest())
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			logger.Infof("执行数据库迁移 %d_%s", migration.Version, migration.Name)
+			if err := m.exec(ctx, conn, migration.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("migration %d_%s up failed: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的数量
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if len(versions) > steps {
+			versions = versions[:steps]
+		}
+
+		// 先检查全部要回滚的版本，避免回滚到一半才失败
+		migrations := make([]*Migration, 0, len(versions))
+		for _, version := range versions {
+			migration := m.find(version)
+			if migration == nil {
+				return fmt.Errorf("migration %d is applied but unknown to this binary", version)
+			}
+			if baseline := squashedBaseline[m.dialect]; migration.Version <= baseline {
+				return fmt.Errorf("%s migration %d_%s is a squashed baseline equivalent to version %d and cannot be rolled back, delete the database to start over",
+					m.dialect, migration.Version, migration.Name, baseline)
+			}
+			migrations = append(migrations, migration)
+		}
+
+		for _, migration := range migrations {
+			logger.Infof("回滚数据库迁移 %d_%s", migration.Version, migration.Name)
+			if err := m.exec(ctx, conn, migration.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s down failed: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status 返回每个内嵌迁移的执行状态，以及数据库中存在但程序不认识的版本
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range applied {
+		appliedAt := record.appliedAt
+		statuses = append(statuses, MigrationStatus{Version: version, Name: record.name, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending 返回尚未执行的内嵌迁移。只读取 schema_migrations，不建表也不加锁，供只读的命令检查 schema 是否为最新
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// withLock 在同一个连接上持有 advisory lock 执行 fn；session 级别的锁与连接绑定，所以迁移必须使用该连接。
+// SQLite 只在本地单实例使用，写事务本身互斥，不需要额外加锁
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if m.dialect == DialectSQLite {
+		if err := ensureMigrationsTable(ctx, conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !locked {
+		logger.Info("其他实例正在执行数据库迁移，等待迁移锁...")
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			logger.Warnf("释放迁移锁失败: %v", err)
+		}
+	}()
+
+	if err := ensureMigrationsTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// exec 在一个事务中执行迁移脚本并更新 schema_migrations
+func (m *Migrator) exec(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var record appliedMigration
+		if err := rows.Scan(&version, &record.name, &record.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func maxVersion(applied map[int64]appliedMigration) int64 {
+	var newest int64
+	for version := range applied {
+		if version > newest {
+			newest = version
+		}
+	}
+	return newest
+}
+
+func ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// checkSchema schema 不是最新时返回错误，供各数据库的 CheckSchema 使用
+func checkSchema(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	migrator, err := NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot read migration state, run the migrate command first if the database is new: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is not up to date: %d pending migrations starting at %d_%s, run the migrate command first",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// migrate 执行所有未执行的迁移并记录日志，供各数据库的 Migrate 使用
+func migrate(ctx context.Context, db *sql.DB, dialect Dialect) error {
+	migrator, err := NewMigrator(db, dialect)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	if applied > 0 {
+		logger.Infof("✅ 数据库迁移完成，本次执行 %d 个", applied)
+	} else {
+		logger.Info("✅ 数据库已是最新版本")
+	}
+	return nil
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS bot_actions;
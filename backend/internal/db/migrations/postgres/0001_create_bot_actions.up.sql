@@ -0,0 +1,17 @@
+-- 初始表结构。早于迁移系统、由 InitSchema 建过表的库已有该表，这里全部使用 IF NOT EXISTS
+CREATE TABLE IF NOT EXISTS bot_actions (
+	id SERIAL PRIMARY KEY,
+	timestamp TIMESTAMP NOT NULL,
+	action_type VARCHAR(20) NOT NULL,
+	amount_a VARCHAR(100) NOT NULL DEFAULT '0',
+	amount_b VARCHAR(100) NOT NULL DEFAULT '0',
+	tx_hash VARCHAR(66) NOT NULL,
+	direction VARCHAR(10),
+	status VARCHAR(20) NOT NULL,
+	gas_used BIGINT,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_actions_timestamp ON bot_actions(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_action_type ON bot_actions(action_type);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_tx_hash ON bot_actions(tx_hash);
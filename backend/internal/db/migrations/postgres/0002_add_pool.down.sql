@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bot_actions_pool;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS pool;
@@ -0,0 +1,3 @@
+-- 多池支持：历史记录归入 default 池
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS pool VARCHAR(64) NOT NULL DEFAULT 'default';
+CREATE INDEX IF NOT EXISTS idx_bot_actions_pool ON bot_actions(pool, timestamp DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_bot_actions_chain_id;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS chain_id;
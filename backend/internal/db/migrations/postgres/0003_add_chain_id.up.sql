@@ -0,0 +1,3 @@
+-- 多链支持：历史记录为 0（未知）
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS chain_id BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_bot_actions_chain_id ON bot_actions(chain_id);
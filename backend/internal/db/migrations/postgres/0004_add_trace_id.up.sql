@@ -0,0 +1,2 @@
+-- 链路追踪：记录产生该操作的 trace ID，未开启追踪时为空
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS trace_id VARCHAR(32) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS gas_cost_wei;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS est_gas_cost_wei;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS fee_value_wei;
@@ -0,0 +1,4 @@
+-- 复投收益：估算的手续费价值、估算与实际 gas 成本（wei），历史记录为空
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS fee_value_wei VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS est_gas_cost_wei VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS gas_cost_wei VARCHAR(78) NOT NULL DEFAULT '';
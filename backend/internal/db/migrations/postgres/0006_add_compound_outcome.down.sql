@@ -0,0 +1,3 @@
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS fee_remainder_b;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS fee_remainder_a;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS lp_minted;
@@ -0,0 +1,4 @@
+-- 复投结果：铸造的 LP 与复投后剩余的手续费，历史记录为空
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS lp_minted VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS fee_remainder_a VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS fee_remainder_b VARCHAR(78) NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS reserve_b_after;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS reserve_a_after;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS reserve_b_before;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS reserve_a_before;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS effective_price;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS token_out;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS token_in;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS amount_out;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS amount_in;
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS block_number;
@@ -0,0 +1,11 @@
+-- 交易所在区块与再平衡结果，历史记录为空
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS block_number BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS amount_in VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS amount_out VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS token_in VARCHAR(1) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS token_out VARCHAR(1) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS effective_price DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS reserve_a_before VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS reserve_b_before VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS reserve_a_after VARCHAR(78) NOT NULL DEFAULT '';
+ALTER TABLE bot_actions ADD COLUMN IF NOT EXISTS reserve_b_after VARCHAR(78) NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- 池状态快照，由再平衡检查按 SNAPSHOT_INTERVAL 记录，用于导出池的历史
+CREATE TABLE IF NOT EXISTS pool_snapshots (
+	id SERIAL PRIMARY KEY,
+	pool VARCHAR(64) NOT NULL,
+	chain_id BIGINT NOT NULL DEFAULT 0,
+	timestamp TIMESTAMP NOT NULL,
+	block_number BIGINT NOT NULL DEFAULT 0,
+	reserve_a VARCHAR(78) NOT NULL,
+	reserve_b VARCHAR(78) NOT NULL,
+	fee_a VARCHAR(78) NOT NULL DEFAULT '',
+	fee_b VARCHAR(78) NOT NULL DEFAULT '',
+	pool_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+	market_price DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_pool_snapshots_pool_timestamp ON pool_snapshots(pool, timestamp);
+CREATE INDEX IF NOT EXISTS idx_pool_snapshots_timestamp ON pool_snapshots(timestamp);
@@ -0,0 +1 @@
+ALTER TABLE bot_actions DROP COLUMN IF EXISTS reserves_approximate;
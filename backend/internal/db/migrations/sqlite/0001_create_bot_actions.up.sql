@@ -0,0 +1,39 @@
+-- SQLite 用于本地开发和单机运行，直接建立与 postgres 目录 0001–0007 相同的表结构（合并的基线）；
+-- 之后的变更与 postgres 目录使用相同的版本号。基线不能回滚，见 migrate.go 的 squashedBaseline
+CREATE TABLE IF NOT EXISTS bot_actions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	pool VARCHAR(64) NOT NULL DEFAULT 'default',
+	chain_id BIGINT NOT NULL DEFAULT 0,
+	timestamp TIMESTAMP NOT NULL,
+	action_type VARCHAR(20) NOT NULL,
+	amount_a VARCHAR(100) NOT NULL DEFAULT '0',
+	amount_b VARCHAR(100) NOT NULL DEFAULT '0',
+	tx_hash VARCHAR(66) NOT NULL,
+	direction VARCHAR(10),
+	status VARCHAR(20) NOT NULL,
+	gas_used BIGINT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	trace_id VARCHAR(32) NOT NULL DEFAULT '',
+	fee_value_wei VARCHAR(78) NOT NULL DEFAULT '',
+	est_gas_cost_wei VARCHAR(78) NOT NULL DEFAULT '',
+	gas_cost_wei VARCHAR(78) NOT NULL DEFAULT '',
+	lp_minted VARCHAR(78) NOT NULL DEFAULT '',
+	fee_remainder_a VARCHAR(78) NOT NULL DEFAULT '',
+	fee_remainder_b VARCHAR(78) NOT NULL DEFAULT '',
+	block_number BIGINT NOT NULL DEFAULT 0,
+	amount_in VARCHAR(78) NOT NULL DEFAULT '',
+	amount_out VARCHAR(78) NOT NULL DEFAULT '',
+	token_in VARCHAR(1) NOT NULL DEFAULT '',
+	token_out VARCHAR(1) NOT NULL DEFAULT '',
+	effective_price DOUBLE PRECISION NOT NULL DEFAULT 0,
+	reserve_a_before VARCHAR(78) NOT NULL DEFAULT '',
+	reserve_b_before VARCHAR(78) NOT NULL DEFAULT '',
+	reserve_a_after VARCHAR(78) NOT NULL DEFAULT '',
+	reserve_b_after VARCHAR(78) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_actions_timestamp ON bot_actions(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_action_type ON bot_actions(action_type);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_tx_hash ON bot_actions(tx_hash);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_pool ON bot_actions(pool, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_chain_id ON bot_actions(chain_id);
@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS idx_bot_actions_timestamp ON bot_actions(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_pool ON bot_actions(pool, timestamp DESC);
+DROP INDEX IF EXISTS idx_bot_actions_status_timestamp;
+DROP INDEX IF EXISTS idx_bot_actions_type_timestamp_id;
+DROP INDEX IF EXISTS idx_bot_actions_pool_timestamp_id;
+DROP INDEX IF EXISTS idx_bot_actions_timestamp_id;
@@ -0,0 +1,7 @@
+-- /api/bot-actions 按 (timestamp, id) 键集分页及常用过滤条件的索引，替代只按 timestamp 排序的旧索引
+CREATE INDEX IF NOT EXISTS idx_bot_actions_timestamp_id ON bot_actions(timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_pool_timestamp_id ON bot_actions(pool, timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_type_timestamp_id ON bot_actions(action_type, timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_bot_actions_status_timestamp ON bot_actions(status, timestamp DESC);
+DROP INDEX IF EXISTS idx_bot_actions_timestamp;
+DROP INDEX IF EXISTS idx_bot_actions_pool;
@@ -0,0 +1,2 @@
+-- 再平衡前后储备之差是否混入了同一区块内其他交易的改动
+ALTER TABLE bot_actions ADD COLUMN reserves_approximate BOOLEAN NOT NULL DEFAULT FALSE;
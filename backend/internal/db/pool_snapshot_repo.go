@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"mini-amm-bot/internal/models"
+)
+
+// PoolSnapshotStore 池状态快照的存储
+type PoolSnapshotStore interface {
+	Create(ctx context.Context, snapshot *models.PoolSnapshot) error
+	Stream(ctx context.Context, filter SnapshotFilter, fn func(snapshot *models.PoolSnapshot) error) error
+}
+
+// SnapshotFilter 快照的过滤条件，时间范围为 [From, To)
+type SnapshotFilter struct {
+	Pool *string
+	From *time.Time
+	To   *time.Time
+}
+
+// PoolSnapshotRepository 基于 SQL 的 PoolSnapshotStore
+type PoolSnapshotRepository struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func NewPoolSnapshotRepository(db *sql.DB, dialect Dialect) *PoolSnapshotRepository {
+	return &PoolSnapshotRepository{db: db, dialect: dialect}
+}
+
+func (r *PoolSnapshotRepository) Create(ctx context.Context, snapshot *models.PoolSnapshot) error {
+	query := `
+		INSERT INTO pool_snapshots (pool, chain_id, timestamp, block_number, reserve_a, reserve_b, fee_a, fee_b, pool_price, market_price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		query,
+		snapshot.Pool,
+		snapshot.ChainID,
+		r.dialect.timeArg(snapshot.Timestamp),
+		snapshot.BlockNumber,
+		snapshot.ReserveA,
+		snapshot.ReserveB,
+		snapshot.FeeA,
+		snapshot.FeeB,
+		snapshot.PoolPrice,
+		snapshot.MarketPrice,
+	).Scan(&snapshot.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create pool snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Stream 按时间升序逐行读取快照并交给 fn，不把结果集读入内存
+func (r *PoolSnapshotRepository) Stream(ctx context.Context, filter SnapshotFilter, fn func(snapshot *models.PoolSnapshot) error) error {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Pool != nil {
+		args = append(args, *filter.Pool)
+		conditions = append(conditions, fmt.Sprintf("pool = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, r.dialect.timeArg(*filter.From))
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, r.dialect.timeArg(*filter.To))
+		conditions = append(conditions, fmt.Sprintf("timestamp < $%d", len(args)))
+	}
+
+	query := `
+		SELECT id, pool, chain_id, timestamp, block_number, reserve_a, reserve_b, fee_a, fee_b, pool_price, market_price
+		FROM pool_snapshots`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY timestamp ASC, id ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query pool snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var snapshot models.PoolSnapshot
+		if err := rows.Scan(
+			&snapshot.ID,
+			&snapshot.Pool,
+			&snapshot.ChainID,
+			&snapshot.Timestamp,
+			&snapshot.BlockNumber,
+			&snapshot.ReserveA,
+			&snapshot.ReserveB,
+			&snapshot.FeeA,
+			&snapshot.FeeB,
+			&snapshot.PoolPrice,
+			&snapshot.MarketPrice,
+		); err != nil {
+			return fmt.Errorf("failed to scan pool snapshot: %w", err)
+		}
+		if err := fn(&snapshot); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	return nil
+}
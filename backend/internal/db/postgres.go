@@ -1,81 +1,94 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
-	log "github.com/sirupsen/logrus"
+	"github.com/lib/pq"
+
+	"mini-amm-bot/internal/logging"
 )
 
+var logger = logging.Component("db")
+
 type PostgresDB struct {
 	db *sql.DB
 }
 
-type Config struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-}
-
-func NewPostgresDB(config Config) (*PostgresDB, error) {
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		config.Host, config.Port, config.User, config.Password, config.DBName)
-
-	db, err := sql.Open("postgres", connStr)
+// NewPostgresDB 连接 PostgreSQL。启动时数据库可能尚未就绪，连接失败会按指数退避重试，
+// 直到超过 config.ConnectTimeout；认证失败、数据库不存在等不会自行恢复的错误直接返回
+func NewPostgresDB(ctx context.Context, config Config) (*PostgresDB, error) {
+	db, err := sql.Open("postgres", config.PostgresDSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Test connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+
+	deadline := time.Now().Add(config.ConnectTimeout)
+	backoff := connectBackoffMin
+	for attempt := 1; ; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			break
+		}
+		if permanentError(err) || time.Now().Add(backoff).After(deadline) {
+			db.Close()
+			return nil, fmt.Errorf("failed to ping database %s after %d attempt(s): %w", config.Describe(), attempt, err)
+		}
+		logger.Warnf("连接 PostgreSQL 失败 (第 %d 次)，%s 后重试: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, connectBackoffMax)
 	}
 
-	// Set connection pool settings
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-
-	log.Info("✅ PostgreSQL 连接成功")
+	logger.Infof("✅ PostgreSQL 连接成功: %s", config.Describe())
 
 	return &PostgresDB{db: db}, nil
 }
 
+// 启动连接重试的退避间隔
+const (
+	connectBackoffMin = time.Second
+	connectBackoffMax = 15 * time.Second
+)
+
+// permanentError 重试无法恢复的错误：认证失败（28xxx）、数据库不存在（3D000）
+func permanentError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code.Class() == "28" || pqErr.Code == "3D000"
+}
+
+// Ping 检查数据库连接是否可用
+func (p *PostgresDB) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
 func (p *PostgresDB) Close() error {
 	return p.db.Close()
 }
 
-func (p *PostgresDB) InitSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS bot_actions (
-		id SERIAL PRIMARY KEY,
-		timestamp TIMESTAMP NOT NULL,
-		action_type VARCHAR(20) NOT NULL,
-		amount_a VARCHAR(100) NOT NULL DEFAULT '0',
-		amount_b VARCHAR(100) NOT NULL DEFAULT '0',
-		tx_hash VARCHAR(66) NOT NULL,
-		direction VARCHAR(10),
-		status VARCHAR(20) NOT NULL,
-		gas_used BIGINT,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW()
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_bot_actions_timestamp ON bot_actions(timestamp DESC);
-	CREATE INDEX IF NOT EXISTS idx_bot_actions_action_type ON bot_actions(action_type);
-	CREATE INDEX IF NOT EXISTS idx_bot_actions_tx_hash ON bot_actions(tx_hash);
-	`
-
-	_, err := p.db.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("failed to initialize schema: %w", err)
-	}
+// Migrate 执行所有未执行的数据库迁移（见 migrations/postgres 目录）
+func (p *PostgresDB) Migrate(ctx context.Context) error {
+	return migrate(ctx, p.db, DialectPostgres)
+}
 
-	log.Info("✅ 数据库表初始化成功")
-	return nil
+// CheckSchema 检查所有迁移都已执行，不修改数据库
+func (p *PostgresDB) CheckSchema(ctx context.Context) error {
+	return checkSchema(ctx, p.db, DialectPostgres)
 }
 
 func (p *PostgresDB) GetDB() *sql.DB {
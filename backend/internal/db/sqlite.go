@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteDB 本地开发和单机运行使用的 SQLite 数据库，path 为 ":memory:" 时只保存在内存中
+type SQLiteDB struct {
+	db *sql.DB
+}
+
+func NewSQLiteDB(path string) (*SQLiteDB, error) {
+	// 时间统一按 SQLite 格式保存，便于与 CURRENT_TIMESTAMP 比较；等待写锁而不是立即返回 SQLITE_BUSY
+	params := url.Values{}
+	params.Add("_time_format", "sqlite")
+	params.Add("_pragma", "busy_timeout(5000)")
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	// 单连接：SQLite 同一时间只允许一个写入者，":memory:" 的每个连接也是独立的数据库
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping sqlite database: %w", err)
+	}
+
+	logger.Infof("✅ SQLite 数据库已打开: %s", path)
+
+	return &SQLiteDB{db: db}, nil
+}
+
+// Ping 检查数据库连接是否可用
+func (s *SQLiteDB) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteDB) Close() error {
+	return s.db.Close()
+}
+
+// Migrate 执行所有未执行的数据库迁移（见 migrations/sqlite 目录）
+func (s *SQLiteDB) Migrate(ctx context.Context) error {
+	return migrate(ctx, s.db, DialectSQLite)
+}
+
+// CheckSchema 检查所有迁移都已执行，不修改数据库
+func (s *SQLiteDB) CheckSchema(ctx context.Context) error {
+	return checkSchema(ctx, s.db, DialectSQLite)
+}
+
+func (s *SQLiteDB) GetDB() *sql.DB {
+	return s.db
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"mini-amm-bot/internal/models"
+)
+
+// statsRow 一个时间段内某种类型和状态的操作记录的聚合结果
+type statsRow struct {
+	bucket          int64 // 时间段起点的 unix 秒
+	actionType      models.ActionType
+	status          string
+	count           int64
+	gasUsed         int64
+	txCount         int64 // gasUsed > 0 的记录数
+	gasCostWei      string
+	volumeAtoB      string
+	volumeBtoA      string
+	feesCompoundedA string
+	feesCompoundedB string
+}
+
+// statsAccumulator 把按 (时间段, 类型, 状态) 分组的结果合并为每个时间段一条 ActionStats
+type statsAccumulator struct {
+	buckets map[int64]*bucketTotals
+}
+
+type bucketTotals struct {
+	stats   models.ActionStats
+	txCount int64
+	gasCost big.Int
+	atob    big.Int
+	btoa    big.Int
+	feesA   big.Int
+	feesB   big.Int
+}
+
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{buckets: map[int64]*bucketTotals{}}
+}
+
+func (a *statsAccumulator) add(row statsRow) {
+	totals, ok := a.buckets[row.bucket]
+	if !ok {
+		totals = &bucketTotals{}
+		a.buckets[row.bucket] = totals
+	}
+
+	counts := &totals.stats.Compound
+	if row.actionType == models.ActionTypeRebalance {
+		counts = &totals.stats.Rebalance
+	}
+	if row.status == "success" {
+		counts.Success += row.count
+	} else {
+		counts.Failed += row.count
+	}
+	totals.stats.Total += row.count
+	totals.stats.GasUsed += uint64(row.gasUsed)
+	totals.txCount += row.txCount
+
+	addAmount(&totals.gasCost, row.gasCostWei)
+	addAmount(&totals.atob, row.volumeAtoB)
+	addAmount(&totals.btoa, row.volumeBtoA)
+	addAmount(&totals.feesA, row.feesCompoundedA)
+	addAmount(&totals.feesB, row.feesCompoundedB)
+}
+
+// result 按时间升序返回各时间段的统计；bucket 为 0 时总是返回一个不带时间段的汇总结果（没有记录时各项为 0）
+func (a *statsAccumulator) result(bucket time.Duration) []models.ActionStats {
+	if bucket == 0 && len(a.buckets) == 0 {
+		a.buckets[0] = &bucketTotals{}
+	}
+	starts := make([]int64, 0, len(a.buckets))
+	for start := range a.buckets {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	result := make([]models.ActionStats, 0, len(starts))
+	for _, start := range starts {
+		totals := a.buckets[start]
+		stats := totals.stats
+		if bucket > 0 {
+			begin := time.Unix(start, 0).UTC()
+			end := begin.Add(bucket)
+			stats.Start, stats.End = &begin, &end
+		}
+		if stats.Total > 0 {
+			stats.SuccessRate = float64(stats.Compound.Success+stats.Rebalance.Success) / float64(stats.Total)
+		}
+		if totals.txCount > 0 {
+			stats.AvgGasUsed = float64(stats.GasUsed) / float64(totals.txCount)
+		}
+		stats.GasCostWei = totals.gasCost.String()
+		stats.VolumeAtoB = totals.atob.String()
+		stats.VolumeBtoA = totals.btoa.String()
+		stats.FeesCompoundedA = totals.feesA.String()
+		stats.FeesCompoundedB = totals.feesB.String()
+		result = append(result, stats)
+	}
+	return result
+}
+
+// addAmount 累加十进制字符串表示的数量，空字符串或无法解析时忽略
+func addAmount(total *big.Int, amount string) {
+	if value, ok := new(big.Int).SetString(amount, 10); ok {
+		total.Add(total, value)
+	}
+}
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Driver 操作记录的存储后端
+type Driver string
+
+const (
+	DriverPostgres Driver = "postgres"
+	DriverSQLite   Driver = "sqlite"
+	DriverMemory   Driver = "memory" // 不持久化，重启后丢失
+)
+
+// PostgreSQL 的 sslmode 取值
+var SSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Config 存储后端配置，由 util.LoadDatabaseConfig 从环境变量读取
+type Config struct {
+	Driver Driver // 为空时使用 postgres
+
+	// PostgreSQL 连接：DSN 非空时直接使用（URL 或 key=value 形式），忽略下面的连接字段
+	DSN         string
+	Host        string
+	Port        int
+	User        string
+	Password    string
+	DBName      string
+	SSLMode     string
+	SSLRootCert string // verify-ca / verify-full 使用的 CA 证书
+	SSLCert     string // 客户端证书，服务端要求证书认证时使用
+	SSLKey      string
+
+	// 连接池
+	MaxOpenConns    int // 0 表示不限制
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // 0 表示不限制
+	ConnMaxIdleTime time.Duration
+
+	ConnectTimeout time.Duration // 启动时连接失败的重试总时长，0 表示只尝试一次
+
+	SQLitePath string // Driver 为 sqlite 时的数据库文件
+}
+
+// PostgresDSN lib/pq 使用的连接串，由连接字段拼成 URL 以正确转义用户名和密码
+func (c Config) PostgresDSN() string {
+	if c.DSN != "" {
+		return c.DSN
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	if c.SSLRootCert != "" {
+		query.Set("sslrootcert", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		query.Set("sslcert", c.SSLCert)
+	}
+	if c.SSLKey != "" {
+		query.Set("sslkey", c.SSLKey)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.DBName,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// Describe 日志中展示的连接目标，不含密码
+func (c Config) Describe() string {
+	switch c.Driver {
+	case DriverSQLite:
+		return "sqlite:" + c.SQLitePath
+	case DriverMemory:
+		return "memory"
+	}
+	if c.DSN == "" {
+		return fmt.Sprintf("%s@%s/%s (sslmode=%s)", c.User, net.JoinHostPort(c.Host, strconv.Itoa(c.Port)), c.DBName, c.SSLMode)
+	}
+	if u, err := url.Parse(c.DSN); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	// key=value 形式的 DSN 只展示 host 和 dbname
+	parts := []string{}
+	for _, field := range strings.Fields(c.DSN) {
+		if strings.HasPrefix(field, "host=") || strings.HasPrefix(field, "dbname=") {
+			parts = append(parts, field)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// timeArg 时间参数统一转为 UTC：SQLite 以文本保存时间，按字符串比较；
+// PostgreSQL 的 TIMESTAMP 不带时区，写入时丢弃偏移，unixSeconds 按 UTC 解释
+func (d Dialect) timeArg(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// unixSeconds 时间列对应的 unix 秒。PostgreSQL 的 TIMESTAMP 不带时区，按 UTC 解释
+func (d Dialect) unixSeconds(column string) string {
+	if d == DialectSQLite {
+		return fmt.Sprintf("CAST(strftime('%%s', %s) AS INTEGER)", column)
+	}
+	return fmt.Sprintf("CAST(FLOOR(EXTRACT(EPOCH FROM %s)) AS BIGINT)", column)
+}
+
+// sumAmount 对十进制字符串保存的数量求和，结果为十进制字符串。
+// SQLite 没有任意精度数值，超过 2^53 的合计会损失精度
+func (d Dialect) sumAmount(expr string) string {
+	if d == DialectSQLite {
+		return fmt.Sprintf("printf('%%.0f', TOTAL(CAST(NULLIF(%s, '') AS REAL)))", expr)
+	}
+	return fmt.Sprintf("CAST(COALESCE(SUM(CAST(NULLIF(%s, '') AS NUMERIC)), 0) AS TEXT)", expr)
+}
+
+// dbSystem OpenTelemetry 的 db.system 属性
+func (d Dialect) dbSystem() string {
+	if d == DialectSQLite {
+		return "sqlite"
+	}
+	return "postgresql"
+}
+
+// Database 存储后端的连接，用于就绪检查和退出时关闭
+type Database interface {
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// Stores 一个存储后端上的各类存储
+type Stores struct {
+	BotActions    BotActionStore
+	PoolSnapshots PoolSnapshotStore
+	Database      Database
+}
+
+// Open 按 config.Driver 连接存储后端、执行迁移并返回其上的各类存储
+func Open(ctx context.Context, config Config) (*Stores, error) {
+	return open(ctx, config, true)
+}
+
+// OpenExisting 与 Open 相同，但不执行迁移，schema 不是最新时返回错误。供导出等只读命令使用
+func OpenExisting(ctx context.Context, config Config) (*Stores, error) {
+	return open(ctx, config, false)
+}
+
+func open(ctx context.Context, config Config, runMigrations bool) (*Stores, error) {
+	switch config.Driver {
+	case DriverPostgres, "":
+		postgres, err := NewPostgresDB(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		prepare := postgres.CheckSchema
+		if runMigrations {
+			prepare = postgres.Migrate
+		}
+		if err := prepare(ctx); err != nil {
+			postgres.Close()
+			return nil, err
+		}
+		return &Stores{
+			BotActions:    NewBotActionRepository(postgres.GetDB()),
+			PoolSnapshots: NewPoolSnapshotRepository(postgres.GetDB(), DialectPostgres),
+			Database:      postgres,
+		}, nil
+	case DriverSQLite:
+		sqlite, err := NewSQLiteDB(config.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		prepare := sqlite.CheckSchema
+		if runMigrations {
+			prepare = sqlite.Migrate
+		}
+		if err := prepare(ctx); err != nil {
+			sqlite.Close()
+			return nil, err
+		}
+		return &Stores{
+			BotActions:    NewSQLiteBotActionRepository(sqlite.GetDB()),
+			PoolSnapshots: NewPoolSnapshotRepository(sqlite.GetDB(), DialectSQLite),
+			Database:      sqlite,
+		}, nil
+	case DriverMemory:
+		logger.Warn("使用内存存储，操作记录在重启后丢失")
+		store := NewMemoryBotActionStore()
+		return &Stores{
+			BotActions:    store,
+			PoolSnapshots: NewMemoryPoolSnapshotStore(),
+			Database:      store,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", config.Driver)
+	}
+}
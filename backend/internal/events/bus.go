@@ -0,0 +1,197 @@
+package events
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
+)
+
+var logger = logging.Component("events")
+
+// 默认保留的最近事件数，断线重连的订阅方可以从中补齐错过的事件
+const DefaultHistorySize = 512
+
+// Bus 进程内的发布/订阅总线，有两种订阅方式：
+//   - Handle 注册的处理函数在发布方的 goroutine 中按注册顺序同步执行，不会丢失事件，用于持久化、指标等；
+//   - Subscribe 的订阅通过缓冲 channel 异步接收，缓冲区满时关闭该订阅而不阻塞发布方，
+//     由订阅方带上最后收到的事件 ID 重新订阅并从历史中补齐，用于 SSE/WebSocket 推送。
+//
+// nil 的 *Bus 可以安全调用 Publish，发布的事件被丢弃
+type Bus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	history     []Event // 环形缓冲区，按 ID 升序保留最近 historySize 个事件
+	historySize int
+	subscribers map[*Subscription]struct{}
+	handlers    []namedHandler
+}
+
+// Handler 同步处理事件，ctx 为发布方的 context（带有 tick 的日志字段与 trace）
+type Handler func(ctx context.Context, event Event)
+
+type namedHandler struct {
+	name string
+	fn   Handler
+}
+
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		nextID:      1,
+		historySize: historySize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Filter 订阅条件，为空的字段不限制
+type Filter struct {
+	Types []Type
+	Pool  string
+}
+
+func (f Filter) match(event Event) bool {
+	if f.Pool != "" && event.Pool != "" && event.Pool != f.Pool {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription 一个订阅，事件从 Events() 读取；channel 被关闭表示订阅结束，Dropped 区分是否因处理太慢被关闭
+type Subscription struct {
+	bus     *Bus
+	filter  Filter
+	events  chan Event
+	dropped bool
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped 订阅是否因缓冲区满被总线关闭
+func (s *Subscription) Dropped() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.dropped
+}
+
+// Close 取消订阅，可以重复调用
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.remove(s)
+}
+
+// Handle 注册同步处理函数，应在开始发布事件前完成注册
+func (b *Bus) Handle(name string, fn Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, namedHandler{name: name, fn: fn})
+}
+
+// Publish 分配 ID 和时间后发送给所有匹配的订阅方，再在当前 goroutine 依次调用处理函数；处理函数中可以继续发布事件
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	event = b.deliver(event)
+
+	b.mu.Lock()
+	handlers := b.handlers
+	b.mu.Unlock()
+	for _, handler := range handlers {
+		b.call(ctx, handler, event)
+	}
+}
+
+// call 执行处理函数，panic 只记录日志，不影响发布方和其他处理函数
+func (b *Bus) call(ctx context.Context, handler namedHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx, logger).Errorf("事件处理函数 %s 处理 %s 时 panic: %v\n%s", handler.name, event.Type, r, debug.Stack())
+		}
+	}()
+	handler.fn(ctx, event)
+}
+
+// deliver 分配 ID、写入历史并发送给异步订阅方，返回带 ID 的事件
+func (b *Bus) deliver(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.Data != nil {
+		event.Type = event.Data.Type()
+	}
+	event.ID = b.nextID
+	b.nextID++
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			b.history = append(b.history[:0], b.history[1:]...)
+		}
+		b.history = append(b.history, event)
+	}
+
+	for sub := range b.subscribers {
+		if !sub.filter.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			logger.Warnf("事件订阅方处理太慢，关闭订阅 (缓冲 %d)", cap(sub.events))
+			sub.dropped = true
+			b.remove(sub)
+			metrics.EventSubscriberDrops.Inc()
+		}
+	}
+	return event
+}
+
+// Subscribe 订阅之后发布的事件；after 大于 0 时先补发历史中 ID 大于 after 的事件。
+// buffer 为订阅方的缓冲大小，至少能容纳需要补发的历史事件
+func (b *Bus) Subscribe(filter Filter, after uint64, buffer int) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay := []Event{}
+	if after > 0 {
+		for _, event := range b.history {
+			if event.ID > after && filter.match(event) {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	sub := &Subscription{bus: b, filter: filter, events: make(chan Event, max(buffer, len(replay)))}
+	for _, event := range replay {
+		sub.events <- event
+	}
+	b.subscribers[sub] = struct{}{}
+	metrics.EventSubscribers.Inc()
+	return sub
+}
+
+// remove 调用方需持有 mu
+func (b *Bus) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.events)
+	metrics.EventSubscribers.Dec()
+}
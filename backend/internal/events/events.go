@@ -0,0 +1,162 @@
+// Package events 进程内的事件总线：服务只发布领域事件，持久化、指标、实时推送等由订阅方处理
+package events
+
+import (
+	"time"
+
+	"mini-amm-bot/internal/models"
+)
+
+// Type 事件类型，SSE 中作为 event 字段
+type Type string
+
+const (
+	TypeTickStarted   Type = "tick_started"
+	TypeTickFinished  Type = "tick_finished"
+	TypeActionSkipped Type = "action_skipped"
+	TypeTxSent        Type = "tx_sent"
+	TypeTxConfirmed   Type = "tx_confirmed"
+	TypeTxFailed      Type = "tx_failed"
+	TypeOracleStale   Type = "oracle_stale"
+	TypePoolState     Type = "pool_state"
+	TypeServiceState  Type = "service_state"
+	TypeRPCFailover   Type = "rpc_failover"
+	TypeBotAction     Type = "bot_action" // 操作记录保存后由持久化订阅方发布
+)
+
+// Types 所有事件类型
+var Types = []Type{
+	TypeTickStarted, TypeTickFinished, TypeActionSkipped, TypeTxSent, TypeTxConfirmed, TypeTxFailed,
+	TypeOracleStale, TypePoolState, TypeServiceState, TypeRPCFailover, TypeBotAction,
+}
+
+// Payload 事件内容，每种事件一个类型
+type Payload interface {
+	Type() Type
+}
+
+// Event 总线上的一个事件，ID 由总线按发布顺序分配，从 1 开始递增
+type Event struct {
+	ID    uint64    `json:"id"`
+	Type  Type      `json:"type"`
+	Time  time.Time `json:"time"`
+	Chain string    `json:"chain,omitempty"`
+	Pool  string    `json:"pool,omitempty"`
+	Data  Payload   `json:"data"`
+}
+
+// TickStarted 一次复投/再平衡检查开始，Trigger 为 schedule 或 swap
+type TickStarted struct {
+	Action  models.ActionType `json:"action"`
+	TickID  string            `json:"tickId"`
+	Trigger string            `json:"trigger"`
+}
+
+// TickFinished 一次检查结束，Error 非空表示读取链上状态、发送或等待交易时出错
+type TickFinished struct {
+	Action   models.ActionType `json:"action"`
+	TickID   string            `json:"tickId"`
+	Duration float64           `json:"durationSeconds"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// SkipReason 未发送交易的原因
+type SkipReason string
+
+const (
+	SkipNotNeeded SkipReason = "not_needed" // 条件不满足，无需执行
+	SkipReadOnly  SkipReason = "readonly"   // 满足条件但处于只读（暂停）模式
+)
+
+type ActionSkipped struct {
+	Action models.ActionType `json:"action"`
+	Reason SkipReason        `json:"reason"`
+	Detail string            `json:"detail,omitempty"`
+}
+
+// TxSent 交易已广播
+type TxSent struct {
+	Action models.ActionType `json:"action"`
+	Hash   string            `json:"hash"`
+	Nonce  uint64            `json:"nonce"`
+}
+
+// TxConfirmed 交易已上链并达到确认数；Reverted 为 true 表示执行失败。Record 为待保存的操作记录
+type TxConfirmed struct {
+	Action           models.ActionType `json:"action"`
+	Hash             string            `json:"hash"`
+	Nonce            uint64            `json:"nonce"`
+	BlockNumber      uint64            `json:"blockNumber"`
+	GasUsed          uint64            `json:"gasUsed"`
+	GasPriceGwei     float64           `json:"gasPriceGwei"`
+	Reverted         bool              `json:"reverted"`
+	ConfirmSeconds   float64           `json:"confirmSeconds"` // 从广播到达到确认数（或确认等待超时）的耗时
+	Confirmations    uint64            `json:"confirmations"`
+	LowConfirmations bool              `json:"lowConfirmations,omitempty"` // CONFIRM_TIMEOUT 内未达到 CONFIRMATIONS，仍可能被重组
+	Record           *models.BotAction `json:"-"`
+}
+
+// TxFailed 交易没有上链：nonce 被另一笔交易使用（Replaced），或等待确认超时
+type TxFailed struct {
+	Action   models.ActionType `json:"action"`
+	Hash     string            `json:"hash"`
+	Nonce    uint64            `json:"nonce"`
+	Replaced bool              `json:"replaced"`
+	Error    string            `json:"error"`
+}
+
+// OracleStale 获取市场价格失败，且最近一次成功获取已超过 OracleMaxAge
+type OracleStale struct {
+	LastUpdated   *time.Time `json:"lastUpdated,omitempty"` // 启动后从未成功获取时为空
+	MaxAgeSeconds float64    `json:"maxAgeSeconds"`
+	Error         string     `json:"error"`
+}
+
+// PoolStateUpdated 再平衡检查读到的池状态
+type PoolStateUpdated struct {
+	BlockNumber uint64  `json:"blockNumber"`
+	ReserveA    string  `json:"reserveA"`
+	ReserveB    string  `json:"reserveB"`
+	FeeA        string  `json:"feeA"` // 尚未复投的手续费
+	FeeB        string  `json:"feeB"`
+	PoolPrice   float64 `json:"poolPrice"`   // reserveB / reserveA
+	MarketPrice float64 `json:"marketPrice"` // 再平衡使用的市场价格
+	Deviation   float64 `json:"deviation"`   // 两侧价值偏差占总价值的比例
+}
+
+// ServiceState 服务状态：暂停时只做检查不发送交易（只读模式）
+type ServiceState string
+
+const (
+	ServicePaused  ServiceState = "paused"
+	ServiceResumed ServiceState = "resumed"
+)
+
+type ServiceStateChanged struct {
+	Service string       `json:"service"` // compound 或 rebalance
+	State   ServiceState `json:"state"`
+}
+
+// RPCFailover 当前 RPC 节点不可用，切换到了另一个节点；只有 Chain，没有 Pool
+type RPCFailover struct {
+	From   string `json:"from"` // 节点 host
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// BotActionRecorded 操作记录已保存
+type BotActionRecorded struct {
+	*models.BotAction
+}
+
+func (TickStarted) Type() Type         { return TypeTickStarted }
+func (TickFinished) Type() Type        { return TypeTickFinished }
+func (ActionSkipped) Type() Type       { return TypeActionSkipped }
+func (TxSent) Type() Type              { return TypeTxSent }
+func (TxConfirmed) Type() Type         { return TypeTxConfirmed }
+func (TxFailed) Type() Type            { return TypeTxFailed }
+func (OracleStale) Type() Type         { return TypeOracleStale }
+func (PoolStateUpdated) Type() Type    { return TypePoolState }
+func (ServiceStateChanged) Type() Type { return TypeServiceState }
+func (RPCFailover) Type() Type         { return TypeRPCFailover }
+func (BotActionRecorded) Type() Type   { return TypeBotAction }
@@ -0,0 +1,210 @@
+// Package export 将操作记录和池快照以 CSV 或 NDJSON 格式流式写出，供 HTTP 接口和 export 子命令共用
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/models"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+func ParseFormat(raw string) (Format, error) {
+	switch format := Format(strings.ToLower(raw)); format {
+	case FormatCSV, FormatNDJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q: want csv or ndjson", raw)
+	}
+}
+
+// ContentType HTTP 响应使用的 Content-Type
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// ParseTime 解析 RFC3339 时间或 unix 秒
+func ParseTime(raw string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+var botActionColumns = []string{
+	"id", "timestamp", "pool", "chain_id", "action_type", "status", "tx_hash", "block_number",
+	"amount_a", "amount_b", "direction", "gas_used", "gas_cost_wei", "gas_cost_eth",
+	"fee_value_wei", "estimated_gas_cost_wei", "lp_minted", "fee_remainder_a", "fee_remainder_b",
+	"amount_in", "amount_out", "token_in", "token_out", "effective_price",
+	"reserve_a_before", "reserve_b_before", "reserve_a_after", "reserve_b_after",
+	"reserves_approximate", "trace_id",
+}
+
+// botActionRecord NDJSON 的一行：操作记录加上以 ETH 表示的 gas 成本
+type botActionRecord struct {
+	*models.BotAction
+	GasCostEth string `json:"gasCostEth,omitempty"`
+}
+
+// BotActions 按时间升序写出满足 filter 的操作记录（忽略分页参数），返回写出的行数
+func BotActions(ctx context.Context, store db.BotActionStore, filter db.QueryFilter, format Format, w io.Writer) (int, error) {
+	filter.Limit, filter.Offset, filter.After = 0, 0, nil
+
+	rows := 0
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(botActionColumns); err != nil {
+			return 0, err
+		}
+		err := store.Stream(ctx, filter, func(action *models.BotAction) error {
+			direction := ""
+			if action.Direction != nil {
+				direction = *action.Direction
+			}
+			effectivePrice := ""
+			if action.EffectivePrice != 0 {
+				effectivePrice = strconv.FormatFloat(action.EffectivePrice, 'g', -1, 64)
+			}
+			rows++
+			return writer.Write([]string{
+				strconv.FormatInt(action.ID, 10),
+				action.Timestamp.UTC().Format(time.RFC3339),
+				action.Pool,
+				strconv.FormatInt(action.ChainID, 10),
+				string(action.ActionType),
+				action.Status,
+				action.TxHash,
+				formatUint(action.BlockNumber),
+				action.AmountA,
+				action.AmountB,
+				direction,
+				formatUint(action.GasUsed),
+				action.GasCostWei,
+				WeiToEther(action.GasCostWei),
+				action.FeeValueWei,
+				action.EstimatedGasCostWei,
+				action.LPMinted,
+				action.FeeRemainderA,
+				action.FeeRemainderB,
+				action.AmountIn,
+				action.AmountOut,
+				action.TokenIn,
+				action.TokenOut,
+				effectivePrice,
+				action.ReserveABefore,
+				action.ReserveBBefore,
+				action.ReserveAAfter,
+				action.ReserveBAfter,
+				strconv.FormatBool(action.ReservesApproximate),
+				action.TraceID,
+			})
+		})
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+		return rows, err
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		err := store.Stream(ctx, filter, func(action *models.BotAction) error {
+			rows++
+			return encoder.Encode(botActionRecord{BotAction: action, GasCostEth: WeiToEther(action.GasCostWei)})
+		})
+		return rows, err
+	default:
+		return 0, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+var poolSnapshotColumns = []string{
+	"id", "timestamp", "pool", "chain_id", "block_number",
+	"reserve_a", "reserve_b", "fee_a", "fee_b", "pool_price", "market_price",
+}
+
+// PoolSnapshots 按时间升序写出满足 filter 的池快照，返回写出的行数
+func PoolSnapshots(ctx context.Context, store db.PoolSnapshotStore, filter db.SnapshotFilter, format Format, w io.Writer) (int, error) {
+	rows := 0
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(poolSnapshotColumns); err != nil {
+			return 0, err
+		}
+		err := store.Stream(ctx, filter, func(snapshot *models.PoolSnapshot) error {
+			rows++
+			return writer.Write([]string{
+				strconv.FormatInt(snapshot.ID, 10),
+				snapshot.Timestamp.UTC().Format(time.RFC3339),
+				snapshot.Pool,
+				strconv.FormatInt(snapshot.ChainID, 10),
+				formatUint(snapshot.BlockNumber),
+				snapshot.ReserveA,
+				snapshot.ReserveB,
+				snapshot.FeeA,
+				snapshot.FeeB,
+				strconv.FormatFloat(snapshot.PoolPrice, 'g', -1, 64),
+				strconv.FormatFloat(snapshot.MarketPrice, 'g', -1, 64),
+			})
+		})
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+		return rows, err
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		err := store.Stream(ctx, filter, func(snapshot *models.PoolSnapshot) error {
+			rows++
+			return encoder.Encode(snapshot)
+		})
+		return rows, err
+	default:
+		return 0, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// WeiToEther 把 wei 精确换算为 ETH 的十进制字符串（不经过浮点），无法解析时返回空字符串
+func WeiToEther(wei string) string {
+	value, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return ""
+	}
+
+	sign := ""
+	if value.Sign() < 0 {
+		sign = "-"
+		value.Neg(value)
+	}
+	whole, frac := new(big.Int).QuoRem(value, big.NewInt(1e18), new(big.Int))
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+	fraction := strings.TrimRight(fmt.Sprintf("%018s", frac.String()), "0")
+	return sign + whole.String() + "." + fraction
+}
+
+func formatUint(value uint64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatUint(value, 10)
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK        Status = "ok"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// Component 单个组件的检查结果
+type Component struct {
+	Name    string                 `json:"name"`
+	Status  Status                 `json:"status"`
+	Message string                 `json:"message,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Check 一个可注册的就绪检查
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) Component
+}
+
+// Report 一次就绪检查的汇总
+type Report struct {
+	Ready      bool        `json:"ready"`
+	CheckedAt  time.Time   `json:"checkedAt"`
+	Components []Component `json:"components"`
+}
+
+// Checker 并发执行所有已注册的检查，任一组件不健康则整体未就绪
+type Checker struct {
+	mu      sync.RWMutex
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Register(checks ...Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, checks...)
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]Check(nil), c.checks...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	components := make([]Component, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			component := check.Run(ctx)
+			component.Name = check.Name
+			components[i] = component
+		}(i, check)
+	}
+	wg.Wait()
+
+	ready := true
+	for _, component := range components {
+		if component.Status != StatusOK {
+			ready = false
+		}
+	}
+
+	return Report{
+		Ready:      ready,
+		CheckedAt:  time.Now(),
+		Components: components,
+	}
+}
+
+// OK 构造健康的检查结果
+func OK(details map[string]interface{}) Component {
+	return Component{Status: StatusOK, Details: details}
+}
+
+// Unhealthy 构造不健康的检查结果
+func Unhealthy(message string, details map[string]interface{}) Component {
+	return Component{Status: StatusUnhealthy, Message: message, Details: details}
+}
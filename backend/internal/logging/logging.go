@@ -0,0 +1,232 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"mini-amm-bot/internal/tracing"
+)
+
+// ServiceName 写入每条日志的 service 字段
+const ServiceName = "mini-amm-keeper"
+
+// 日志输出格式
+const (
+	FormatText = "text" // 带时间戳的文本格式，适合本地查看
+	FormatJSON = "json" // 每行一个 JSON 对象，适合日志聚合系统
+)
+
+// MainComponent 标准 logger（log.Infof 等）对应的组件名
+const MainComponent = "main"
+
+type registry struct {
+	mu           sync.Mutex
+	formatter    log.Formatter
+	defaultLevel log.Level
+	overrides    map[string]log.Level // 单独设置过级别的组件
+	loggers      map[string]*log.Logger
+}
+
+var reg = &registry{
+	formatter:    newFormatter(FormatText),
+	defaultLevel: log.InfoLevel,
+	overrides:    map[string]log.Level{},
+	loggers:      map[string]*log.Logger{},
+}
+
+func init() {
+	std := log.StandardLogger()
+	std.AddHook(componentHook{component: MainComponent})
+	reg.loggers[MainComponent] = std
+}
+
+// componentHook 为每条日志补充 service 和 component 字段
+type componentHook struct {
+	component string
+}
+
+func (h componentHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h componentHook) Fire(entry *log.Entry) error {
+	if _, ok := entry.Data["service"]; !ok {
+		entry.Data["service"] = ServiceName
+	}
+	if _, ok := entry.Data["component"]; !ok {
+		entry.Data["component"] = h.component
+	}
+	return nil
+}
+
+func newFormatter(format string) log.Formatter {
+	if format == FormatJSON {
+		return &log.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	}
+	return &log.TextFormatter{FullTimestamp: true}
+}
+
+// Setup 设置输出格式、默认级别和各组件级别，已创建的组件 logger 同步更新。
+// componentLevels 形如 "rpc=debug,tx=warn"。
+func Setup(format, level, componentLevels string) error {
+	if format != FormatText && format != FormatJSON {
+		return fmt.Errorf("LOG_FORMAT 无效: %s (可选 %s, %s)", format, FormatText, FormatJSON)
+	}
+	defaultLevel, err := log.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("LOG_LEVEL 无效: %w", err)
+	}
+	overrides, err := parseComponentLevels(componentLevels)
+	if err != nil {
+		return err
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.formatter = newFormatter(format)
+	reg.defaultLevel = defaultLevel
+	reg.overrides = overrides
+	for name, logger := range reg.loggers {
+		logger.SetFormatter(reg.formatter)
+		logger.SetLevel(reg.levelFor(name))
+	}
+	return nil
+}
+
+func parseComponentLevels(value string) (map[string]log.Level, error) {
+	overrides := map[string]log.Level{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, levelStr, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("LOG_LEVELS 格式无效: %s (应为 组件=级别)", item)
+		}
+		level, err := log.ParseLevel(strings.TrimSpace(levelStr))
+		if err != nil {
+			return nil, fmt.Errorf("LOG_LEVELS 中组件 %s 的级别无效: %w", name, err)
+		}
+		overrides[strings.TrimSpace(name)] = level
+	}
+	return overrides, nil
+}
+
+// levelFor 调用方需持有 mu
+func (r *registry) levelFor(component string) log.Level {
+	if level, ok := r.overrides[component]; ok {
+		return level
+	}
+	return r.defaultLevel
+}
+
+// Component 返回组件专属的 logger，同名组件共享同一个 logger，级别可单独调整
+func Component(name string) *log.Entry {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	logger, ok := reg.loggers[name]
+	if !ok {
+		std := log.StandardLogger()
+		logger = log.New()
+		logger.SetOutput(std.Out)
+		logger.SetFormatter(reg.formatter)
+		logger.SetLevel(reg.levelFor(name))
+		logger.AddHook(componentHook{component: name})
+		reg.loggers[name] = logger
+	}
+	return log.NewEntry(logger)
+}
+
+// LevelsSnapshot 当前默认级别和各组件的生效级别
+type LevelsSnapshot struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+// Levels 返回当前日志级别
+func Levels() LevelsSnapshot {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	snapshot := LevelsSnapshot{
+		Default:    reg.defaultLevel.String(),
+		Components: make(map[string]string, len(reg.loggers)),
+	}
+	for name, logger := range reg.loggers {
+		snapshot.Components[name] = logger.GetLevel().String()
+	}
+	return snapshot
+}
+
+// SetLevel 运行时调整日志级别；component 为空时调整默认级别（不影响单独设置过的组件）
+func SetLevel(component, level string) error {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if component == "" {
+		reg.defaultLevel = lvl
+		for name, logger := range reg.loggers {
+			logger.SetLevel(reg.levelFor(name))
+		}
+		return nil
+	}
+
+	logger, ok := reg.loggers[component]
+	if !ok {
+		return fmt.Errorf("未知组件: %s", component)
+	}
+	reg.overrides[component] = lvl
+	logger.SetLevel(lvl)
+	return nil
+}
+
+type fieldsKey struct{}
+
+// WithFields 把日志字段附加到 ctx，之后通过 FromContext 取得的日志都带有这些字段
+func WithFields(ctx context.Context, fields log.Fields) context.Context {
+	merged := log.Fields{}
+	if existing, ok := ctx.Value(fieldsKey{}).(log.Fields); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext 为 entry 附加 ctx 中的日志字段和 trace_id
+func FromContext(ctx context.Context, entry *log.Entry) *log.Entry {
+	if fields, ok := ctx.Value(fieldsKey{}).(log.Fields); ok {
+		entry = entry.WithFields(fields)
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		entry = entry.WithField("trace_id", traceID)
+	}
+	return entry
+}
+
+// NewTickID 生成一次 tick 的关联 ID
+func NewTickID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
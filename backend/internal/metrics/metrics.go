@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "keeper"
+
+// 操作结果标签取值
+const (
+	OutcomeSuccess  = "success"  // 交易上链且执行成功
+	OutcomeReverted = "reverted" // 交易上链但执行失败
+	OutcomeError    = "error"    // 读取链上状态、发送或等待交易时出错
+	OutcomeSkipped  = "skipped"  // 条件不满足，无需执行
+	OutcomeReadOnly = "readonly" // 满足条件但处于只读模式
+)
+
+// 多节点一致性读取结果标签取值
+const (
+	QuorumAgreed = "agreed"
+	QuorumFailed = "failed"
+)
+
+var (
+	ActionAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "action_attempts_total",
+		Help:      "Compound/rebalance attempts by outcome.",
+	}, []string{"chain", "pool", "action", "outcome"})
+
+	GasUsed = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tx_gas_used",
+		Help:      "Gas used by mined keeper transactions.",
+		Buckets:   prometheus.ExponentialBuckets(25000, 1.5, 10),
+	}, []string{"chain", "pool", "action"})
+
+	GasPrice = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tx_gas_price_gwei",
+		Help:      "Effective gas price paid by mined keeper transactions, in gwei.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	}, []string{"chain", "pool", "action"})
+
+	TxConfirmationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tx_confirmation_seconds",
+		Help:      "Time from broadcast to receipt with the configured confirmation depth.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+	}, []string{"chain", "pool", "action"})
+
+	RPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Latency of JSON-RPC calls per endpoint and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"chain", "endpoint", "method"})
+
+	RPCErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Failed JSON-RPC calls per endpoint and method.",
+	}, []string{"chain", "endpoint", "method"})
+
+	RPCEndpointUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_endpoint_up",
+		Help:      "Whether an RPC endpoint passed its last health check and is not lagging (1) or not (0).",
+	}, []string{"chain", "endpoint"})
+
+	RPCEndpointBlock = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_endpoint_block_number",
+		Help:      "Latest block number reported by each RPC endpoint.",
+	}, []string{"chain", "endpoint"})
+
+	RPCFailovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_failovers_total",
+		Help:      "RPC endpoint switches caused by errors or failed health checks.",
+	}, []string{"chain", "from", "to"})
+
+	RPCQuorumReads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_quorum_reads_total",
+		Help:      "Multi-endpoint quorum reads by result (agreed/failed).",
+	}, []string{"chain", "result"})
+
+	RPCDivergences = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_divergences_total",
+		Help:      "Quorum reads where an endpoint disagreed with the majority at the same block.",
+	}, []string{"chain", "endpoint"})
+
+	SwapEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "swap_events_total",
+		Help:      "Swap events received over the WebSocket subscription, by size relative to reserves.",
+	}, []string{"chain", "pool", "size"})
+
+	EventSubscriptionUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "event_subscription_up",
+		Help:      "Whether the Swap event subscription used by event-driven rebalancing is active (1) or not (0).",
+	}, []string{"chain", "pool"})
+
+	PoolReserve = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pool_reserve",
+		Help:      "Pool reserves in token base units.",
+	}, []string{"chain", "pool", "token"})
+
+	PoolFee = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pool_fee_accumulated",
+		Help:      "Uncompounded fee accumulators (feeA/feeB) in token base units.",
+	}, []string{"chain", "pool", "token"})
+
+	OraclePrice = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "oracle_price",
+		Help:      "Latest market price of token A in token B used for rebalancing.",
+	}, []string{"chain", "pool"})
+
+	OracleDeviation = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "oracle_deviation_ratio",
+		Help:      "Pool value imbalance measured at the market price (0 = balanced).",
+	}, []string{"chain", "pool"})
+
+	CompoundEconomics = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "compound_economics_eth",
+		Help:      "Latest compound profitability estimate in ETH: kind=fee_value or gas_cost.",
+	}, []string{"chain", "pool", "kind"})
+
+	SignerBalance = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "signer_balance_eth",
+		Help:      "ETH balance of the keeper signer account.",
+	}, []string{"chain", "address"})
+
+	EventSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "event_subscribers",
+		Help:      "Active subscriptions to the in-process event bus (SSE/WebSocket clients).",
+	})
+
+	EventSubscriberDrops = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_subscriber_drops_total",
+		Help:      "Event bus subscriptions closed because the subscriber fell behind.",
+	})
+
+	AlertNotifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alert_notifications_total",
+		Help:      "Alert notifications delivered to each sink, by rule, status (firing/resolved) and result (sent/failed).",
+	}, []string{"rule", "sink", "status", "result"})
+
+	AlertsSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_suppressed_total",
+		Help:      "Firing alerts not sent because the same alert was notified within the cooldown.",
+	}, []string{"rule"})
+)
+
+// Handler 返回 /metrics 的 HTTP 处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveRPC 记录一次 RPC 调用的耗时和错误
+func ObserveRPC(chain, endpoint, method string, start time.Time, err error) {
+	RPCRequestDuration.WithLabelValues(chain, endpoint, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		RPCErrors.WithLabelValues(chain, endpoint, method).Inc()
+	}
+}
+
+// BoolToFloat 把布尔状态转换为 gauge 取值 1/0
+func BoolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ToFloat 把 wei 等大整数转换为 float64，仅用于指标展示
+func ToFloat(i *big.Int) float64 {
+	if i == nil {
+		return 0
+	}
+	f, _ := new(big.Float).SetInt(i).Float64()
+	return f
+}
+
+// WeiToUnit 把 wei 按 10^decimals 换算为浮点数，例如 wei -> ETH、wei -> gwei
+func WeiToUnit(wei *big.Int, decimals int) float64 {
+	if wei == nil {
+		return 0
+	}
+	f := new(big.Float).SetInt(wei)
+	f.Quo(f, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	v, _ := f.Float64()
+	return v
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// StatusCount 某类操作按状态的次数
+type StatusCount struct {
+	Success int64 `json:"success"`
+	Failed  int64 `json:"failed"`
+}
+
+// ActionStats 一段时间内操作记录的汇总统计，数量均为最小单位的十进制字符串
+type ActionStats struct {
+	Start *time.Time `json:"start,omitempty"` // 时间段 [Start, End)，汇总整个查询范围且未指定范围时为空
+	End   *time.Time `json:"end,omitempty"`
+
+	Compound    StatusCount `json:"compound"`
+	Rebalance   StatusCount `json:"rebalance"`
+	Total       int64       `json:"total"`
+	SuccessRate float64     `json:"successRate"` // 成功次数 / 总次数，没有记录时为 0
+
+	GasUsed    uint64  `json:"gasUsed"`
+	AvgGasUsed float64 `json:"avgGasUsed"` // 按上链交易（gasUsed > 0）平均
+	GasCostWei string  `json:"gasCostWei"`
+	GasCostEth string  `json:"gasCostEth"`
+
+	// 成功的再平衡投入的数量：AtoB 为 tokenA，BtoA 为 tokenB
+	VolumeAtoB string `json:"volumeAtoB"`
+	VolumeBtoA string `json:"volumeBtoA"`
+
+	// 成功复投的手续费数量
+	FeesCompoundedA string `json:"feesCompoundedA"`
+	FeesCompoundedB string `json:"feesCompoundedB"`
+}
@@ -11,6 +11,8 @@ const (
 
 type BotAction struct {
 	ID         int64      `json:"id"`
+	Pool       string     `json:"pool"`
+	ChainID    int64      `json:"chainId"`
 	Timestamp  time.Time  `json:"timestamp"`
 	ActionType ActionType `json:"actionType"`
 	AmountA    string     `json:"amountA"`
@@ -20,4 +22,33 @@ type BotAction struct {
 	Status     string     `json:"status"`              // "success" or "failed"
 	GasUsed    uint64     `json:"gasUsed,omitempty"`
 	CreatedAt  time.Time  `json:"createdAt"`
+	TraceID    string     `json:"traceId,omitempty"` // 产生该记录的 tick 的 OpenTelemetry trace ID
+
+	// 交易所在区块，未上链时为 0
+	BlockNumber uint64 `json:"blockNumber,omitempty"`
+
+	// 复投收益（wei）：发送前估算的手续费价值与 gas 成本，以及上链后的实际 gas 成本
+	FeeValueWei         string `json:"feeValueWei,omitempty"`
+	EstimatedGasCostWei string `json:"estimatedGasCostWei,omitempty"`
+	GasCostWei          string `json:"gasCostWei,omitempty"`
+
+	// 复投结果：铸造的 LP 数量，以及复投后因两侧不配平留在 feeA/feeB 中的剩余手续费
+	LPMinted      string `json:"lpMinted,omitempty"`
+	FeeRemainderA string `json:"feeRemainderA,omitempty"`
+	FeeRemainderB string `json:"feeRemainderB,omitempty"`
+
+	// 再平衡结果：Rebalance 事件中的输入/输出数量及对应的代币（"A" 或 "B"），
+	// 成交价格（每个 tokenA 换多少 tokenB），以及交易所在区块前后的储备量；
+	// ReservesApproximate 表示同一区块内还有其他交易改动了储备
+	AmountIn       string  `json:"amountIn,omitempty"`
+	AmountOut      string  `json:"amountOut,omitempty"`
+	TokenIn        string  `json:"tokenIn,omitempty"`
+	TokenOut       string  `json:"tokenOut,omitempty"`
+	EffectivePrice float64 `json:"effectivePrice,omitempty"`
+	ReserveABefore string  `json:"reserveABefore,omitempty"`
+	ReserveBBefore string  `json:"reserveBBefore,omitempty"`
+	ReserveAAfter  string  `json:"reserveAAfter,omitempty"`
+	ReserveBAfter  string  `json:"reserveBAfter,omitempty"`
+
+	ReservesApproximate bool `json:"reservesApproximate,omitempty"`
 }
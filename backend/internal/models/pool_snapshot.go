@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// PoolSnapshot 定期记录的池状态，用于导出池的历史储备、手续费与价格
+type PoolSnapshot struct {
+	ID          int64     `json:"id"`
+	Pool        string    `json:"pool"`
+	ChainID     int64     `json:"chainId"`
+	Timestamp   time.Time `json:"timestamp"`
+	BlockNumber uint64    `json:"blockNumber"`
+	ReserveA    string    `json:"reserveA"`
+	ReserveB    string    `json:"reserveB"`
+	FeeA        string    `json:"feeA"` // 尚未复投的手续费
+	FeeB        string    `json:"feeB"`
+	PoolPrice   float64   `json:"poolPrice"`   // reserveB / reserveA
+	MarketPrice float64   `json:"marketPrice"` // 再平衡使用的市场价格
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func utc(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestCronNext(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"每天零点", "0 0 * * *", utc(2026, 10, 19, 13, 7), utc(2026, 10, 20, 0, 0)},
+		{"每天零点不含当前时间", "0 0 * * *", utc(2026, 10, 20, 0, 0), utc(2026, 10, 21, 0, 0)},
+		{"每天零点忽略秒", "0 0 * * *", utc(2026, 10, 19, 23, 59).Add(59 * time.Second), utc(2026, 10, 20, 0, 0)},
+		{"每 15 分钟", "*/15 * * * *", utc(2026, 10, 19, 12, 7), utc(2026, 10, 19, 12, 15)},
+		{"每 15 分钟不含当前时间", "*/15 * * * *", utc(2026, 10, 19, 12, 15), utc(2026, 10, 19, 12, 30)},
+		{"每 15 分钟跨小时", "*/15 * * * *", utc(2026, 10, 19, 12, 45).Add(30 * time.Second), utc(2026, 10, 19, 13, 0)},
+		{"每 15 分钟跨天", "*/15 * * * *", utc(2026, 10, 19, 23, 50), utc(2026, 10, 20, 0, 0)},
+		// 日和周都有限制时满足其一即可：每月 1 日或每周一
+		{"日或周: 先到周一", "0 0 1 * 1", utc(2026, 10, 19, 12, 0), utc(2026, 10, 26, 0, 0)},
+		{"日或周: 先到 1 日（周日）", "0 0 1 * 1", utc(2026, 10, 26, 0, 0), utc(2026, 11, 1, 0, 0)},
+		{"日或周: 1 日之后的周一", "0 0 1 * 1", utc(2026, 11, 1, 0, 0), utc(2026, 11, 2, 0, 0)},
+		{"只限制日", "0 0 1 * *", utc(2026, 10, 19, 12, 0), utc(2026, 11, 1, 0, 0)},
+		{"只限制周", "0 0 * * 1", utc(2026, 10, 27, 12, 0), utc(2026, 11, 2, 0, 0)},
+		{"周 7 表示周日", "0 0 * * 7", utc(2026, 10, 19, 12, 0), utc(2026, 10, 25, 0, 0)},
+		{"2 月 29 日跳到闰年", "0 0 29 2 *", utc(2025, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"2 月 29 日不含当前时间", "0 0 29 2 *", utc(2024, 2, 29, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"2 月 29 日当天", "30 12 29 2 *", utc(2024, 2, 29, 8, 0), utc(2024, 2, 29, 12, 30)},
+		{"跨月", "0 0 * * *", utc(2026, 1, 31, 23, 30), utc(2026, 2, 1, 0, 0)},
+		{"跨闰年 2 月末", "0 0 * * *", utc(2024, 2, 28, 23, 30), utc(2024, 2, 29, 0, 0)},
+		{"跨年", "0 0 * * *", utc(2026, 12, 31, 23, 59), utc(2027, 1, 1, 0, 0)},
+		{"每月 31 日跳过小月", "0 0 31 * *", utc(2026, 4, 1, 0, 0), utc(2026, 5, 31, 0, 0)},
+		{"指定月份跨年", "0 0 1 3 *", utc(2026, 3, 1, 0, 0), utc(2027, 3, 1, 0, 0)},
+		{"非 UTC 输入按 UTC 计算", "0 0 * * *", time.Date(2027, 1, 1, 7, 30, 0, 0, shanghai), utc(2027, 1, 1, 0, 0)},
+		{"范围与列表", "0,30 9-10 * * 1-5", utc(2026, 10, 23, 10, 30), utc(2026, 10, 26, 9, 0)},
+		{"单值带步长", "5/20 * * * *", utc(2026, 10, 19, 12, 30), utc(2026, 10, 19, 12, 45)},
+		{"别名", "@hourly", utc(2026, 10, 19, 12, 7), utc(2026, 10, 19, 13, 0)},
+		{"不存在的日期", "0 0 30 2 *", utc(2026, 1, 1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@every 5m",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) 应返回错误", expr)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mini-amm-bot/internal/logging"
+)
+
+// 调度方式
+const (
+	KindInterval = "interval" // 固定间隔，等价于原来的 time.Ticker
+	KindCron     = "cron"     // cron 表达式（UTC）
+	KindBlocks   = "blocks"   // 每 N 个区块
+)
+
+// 按区块调度时查询最新区块的间隔
+const blockPollInterval = 2 * time.Second
+
+var logger = logging.Component("scheduler")
+
+// Spec 解析后的调度规则
+type Spec struct {
+	Kind     string
+	Interval time.Duration
+	Blocks   uint64
+	Expr     string // 原始 cron 表达式
+	cron     *cronSchedule
+
+	periodOnce sync.Once
+	period     time.Duration
+}
+
+// Parse 解析调度规则：
+//   - 空字符串：固定间隔 fallback
+//   - "@every 5m"：固定间隔
+//   - "@blocks 10"：每 10 个区块
+//   - "0 0 * * *" 或 "@daily" 等：cron 表达式（UTC）
+func Parse(spec string, fallback time.Duration) (*Spec, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		if fallback <= 0 {
+			return nil, fmt.Errorf("执行间隔必须大于 0")
+		}
+		return &Spec{Kind: KindInterval, Interval: fallback}, nil
+	case strings.HasPrefix(spec, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("调度规则 %q 的间隔无效", spec)
+		}
+		return &Spec{Kind: KindInterval, Interval: interval}, nil
+	case strings.HasPrefix(spec, "@blocks "):
+		blocks, err := strconv.ParseUint(strings.TrimSpace(strings.TrimPrefix(spec, "@blocks ")), 10, 64)
+		if err != nil || blocks == 0 {
+			return nil, fmt.Errorf("调度规则 %q 的区块数无效", spec)
+		}
+		return &Spec{Kind: KindBlocks, Blocks: blocks}, nil
+	default:
+		cron, err := parseCron(spec)
+		if err != nil {
+			return nil, err
+		}
+		if cron.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("cron 表达式 %q 永远不会触发", spec)
+		}
+		return &Spec{Kind: KindCron, Expr: spec, cron: cron}, nil
+	}
+}
+
+func (s *Spec) String() string {
+	switch s.Kind {
+	case KindInterval:
+		return "@every " + s.Interval.String()
+	case KindBlocks:
+		return fmt.Sprintf("@blocks %d", s.Blocks)
+	default:
+		return s.Expr
+	}
+}
+
+// Period 两次触发之间的最长间隔，供就绪检查判断任务是否卡住；
+// cron 取未来 8 天内触发间隔的最大值（覆盖按工作日、按周的规则），按区块调度时出块时间未知，返回 fallback
+func (s *Spec) Period(fallback time.Duration) time.Duration {
+	switch s.Kind {
+	case KindInterval:
+		return s.Interval
+	case KindCron:
+		s.periodOnce.Do(func() {
+			prev := s.cron.Next(time.Now())
+			end := prev.AddDate(0, 0, 8)
+			for !prev.IsZero() && prev.Before(end) {
+				next := s.cron.Next(prev)
+				if next.IsZero() {
+					break
+				}
+				if gap := next.Sub(prev); gap > s.period {
+					s.period = gap
+				}
+				prev = next
+			}
+		})
+		if s.period > 0 {
+			return s.period
+		}
+	}
+	return fallback
+}
+
+// BlockSource 返回最新区块高度，按区块调度时使用
+type BlockSource func(ctx context.Context) (uint64, error)
+
+// Status 任务的调度状态，供 API 展示
+type Status struct {
+	Name          string     `json:"name"`
+	Kind          string     `json:"kind"`
+	Schedule      string     `json:"schedule"`
+	JitterSeconds float64    `json:"jitterSeconds"`
+	NextRunAt     *time.Time `json:"nextRunAt,omitempty"`
+	NextBlock     uint64     `json:"nextBlock,omitempty"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`
+	LastBlock     uint64     `json:"lastBlock,omitempty"`
+}
+
+// Job 一个按 Spec 触发的任务；触发通过 Start 返回的 channel 送达，上一次触发未被取走时不会累积
+type Job struct {
+	name   string
+	spec   *Spec
+	jitter time.Duration
+	blocks BlockSource
+	reset  chan struct{} // SetInterval 后通知调度循环重新计算下次执行时间
+
+	mu        sync.Mutex
+	nextRunAt time.Time
+	nextBlock uint64
+	lastRunAt time.Time
+	lastBlock uint64
+}
+
+// Start 开始调度，ctx 取消后停止；返回的 channel 每次触发收到一个值
+func (j *Job) Start(ctx context.Context) <-chan struct{} {
+	fires := make(chan struct{})
+	go j.run(ctx, fires)
+	return fires
+}
+
+func (j *Job) run(ctx context.Context, fires chan<- struct{}) {
+	log := logger.WithField("job", j.name)
+	log.Infof("任务调度已启动: %s (抖动 %s)", j.Spec(), j.jitter)
+
+	spec := j.Spec()
+	var head uint64
+	if spec.Kind == KindBlocks {
+		var ok bool
+		if head, ok = j.waitHead(ctx); !ok {
+			return
+		}
+	}
+
+	last := time.Now()
+	for {
+		spec = j.Spec()
+		var at time.Time
+		switch spec.Kind {
+		case KindInterval:
+			at = last.Add(spec.Interval)
+		case KindCron:
+			at = spec.cron.Next(time.Now())
+			if at.IsZero() {
+				log.Error("cron 表达式在 5 年内不再触发，停止调度")
+				return
+			}
+		case KindBlocks:
+			target := head + spec.Blocks
+			j.setNext(time.Time{}, target)
+			var ok bool
+			if head, ok = j.waitBlock(ctx, target); !ok {
+				return
+			}
+			at = time.Now()
+		}
+		at = at.Add(j.randomJitter())
+		if spec.Kind != KindBlocks {
+			j.setNext(at, 0)
+		}
+
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-j.reset:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case fires <- struct{}{}:
+		}
+		last = time.Now()
+		j.markRun(last, head)
+	}
+}
+
+// waitHead 获取当前区块高度，失败时持续重试直到成功或 ctx 取消
+func (j *Job) waitHead(ctx context.Context) (uint64, bool) {
+	for {
+		head, err := j.blocks(ctx)
+		if err == nil {
+			return head, true
+		}
+		logger.WithField("job", j.name).Warnf("获取最新区块失败: %v", err)
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-time.After(blockPollInterval):
+		}
+	}
+}
+
+// waitBlock 轮询最新区块直到达到 target，返回到达时的区块高度
+func (j *Job) waitBlock(ctx context.Context, target uint64) (uint64, bool) {
+	ticker := time.NewTicker(blockPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-ticker.C:
+		}
+		head, err := j.blocks(ctx)
+		if err != nil {
+			logger.WithField("job", j.name).Debugf("获取最新区块失败: %v", err)
+			continue
+		}
+		if head >= target {
+			return head, true
+		}
+	}
+}
+
+func (j *Job) randomJitter() time.Duration {
+	if j.jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(j.jitter)))
+}
+
+func (j *Job) setNext(at time.Time, block uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.nextRunAt = at
+	j.nextBlock = block
+}
+
+func (j *Job) markRun(at time.Time, block uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.lastRunAt = at
+	if j.spec.Kind == KindBlocks {
+		j.lastBlock = block
+	}
+}
+
+// Spec 当前生效的调度规则
+func (j *Job) Spec() *Spec {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.spec
+}
+
+// SetInterval 运行中调整固定间隔任务的间隔，下次执行时间从上次执行起按新间隔重新计算
+func (j *Job) SetInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("执行间隔必须大于 0")
+	}
+	j.mu.Lock()
+	if j.spec.Kind != KindInterval {
+		j.mu.Unlock()
+		return fmt.Errorf("任务 %s 使用 %s 调度，不能调整间隔", j.name, j.spec)
+	}
+	j.spec = &Spec{Kind: KindInterval, Interval: interval}
+	j.mu.Unlock()
+
+	select {
+	case j.reset <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Period 两次触发之间的最长间隔（含抖动），见 Spec.Period
+func (j *Job) Period(fallback time.Duration) time.Duration {
+	return j.Spec().Period(fallback) + j.jitter
+}
+
+// Status 当前调度状态
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := Status{
+		Name:          j.name,
+		Kind:          j.spec.Kind,
+		Schedule:      j.spec.String(),
+		JitterSeconds: j.jitter.Seconds(),
+		NextBlock:     j.nextBlock,
+		LastBlock:     j.lastBlock,
+	}
+	if !j.nextRunAt.IsZero() {
+		next := j.nextRunAt
+		status.NextRunAt = &next
+	}
+	if !j.lastRunAt.IsZero() {
+		last := j.lastRunAt
+		status.LastRunAt = &last
+	}
+	return status
+}
+
+// Scheduler 所有任务的注册表，复投与再平衡服务共用
+type Scheduler struct {
+	mu   sync.RWMutex
+	jobs []*Job
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add 注册一个任务；按区块调度时 blocks 不能为空
+func (s *Scheduler) Add(name string, spec *Spec, jitter time.Duration, blocks BlockSource) (*Job, error) {
+	if spec.Kind == KindBlocks && blocks == nil {
+		return nil, fmt.Errorf("任务 %s 按区块调度但未提供区块来源", name)
+	}
+	job := &Job{name: name, spec: spec, jitter: jitter, blocks: blocks, reset: make(chan struct{}, 1)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	return job, nil
+}
+
+// Jobs 所有任务的调度状态
+func (s *Scheduler) Jobs() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		statuses = append(statuses, job.Status())
+	}
+	return statuses
+}
@@ -2,26 +2,47 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
 	"mini-amm-bot/internal/models"
+	"mini-amm-bot/internal/scheduler"
+	"mini-amm-bot/internal/tracing"
 	util "mini-amm-bot/internal/util"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type CompoundService struct {
 	config    *util.Config
+	pool      *util.PoolConfig
 	rpcClient *util.RPCClient
 	txService *TransactionService
 	contract  *MiniAMMContract
-	repo      *db.BotActionRepository
+	repo      db.BotActionStore
+	bus       *events.Bus
+	job       *scheduler.Job
+	logger    *log.Entry
+	readOnly  atomic.Bool // 只读模式下只做检查不发送交易
+
+	tickTracker
+	priceUpdatedAt atomic.Int64 // 最近一次成功获取市场价格的时间（UnixNano）
+
+	tokenBMu       sync.Mutex
+	tokenBDecimals *uint8 // tokenB 精度，首次估算复投收益时读取
 }
 
 // // 假设你已经生成了 UniswapV2Pair binding
@@ -45,38 +66,96 @@ type CompoundService struct {
 // 	}, nil
 // }
 
-func NewCompoundService(config *util.Config, rpcClient *util.RPCClient, txService *TransactionService, repo *db.BotActionRepository) (*CompoundService, error) {
-	contract, err := NewMiniAMMContract(common.HexToAddress(config.ContractAddress), rpcClient.GetClient())
+func NewCompoundService(config *util.Config, pool *util.PoolConfig, rpcClient *util.RPCClient, txService *TransactionService, repo db.BotActionStore, bus *events.Bus, sched *scheduler.Scheduler) (*CompoundService, error) {
+	if chain := rpcClient.Chain(); chain.Name != pool.Chain {
+		return nil, fmt.Errorf("池 %s 属于链 %s，但传入的 RPC 客户端连接的是 %s", pool.Name, pool.Chain, chain.Name)
+	}
+
+	contract, err := NewMiniAMMContract(common.HexToAddress(pool.ContractAddress), rpcClient)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := addJob(sched, pool, "compound", pool.CompoundSchedule, pool.CompoundInterval, rpcClient)
 	if err != nil {
 		return nil, err
 	}
 
 	return &CompoundService{
 		config:    config,
+		pool:      pool,
 		rpcClient: rpcClient,
 		txService: txService,
 		contract:  contract,
 		repo:      repo,
+		bus:       bus,
+		job:       job,
+		logger:    logging.Component("compound").WithFields(log.Fields{"pool": pool.Name, "chain": pool.Chain}),
 	}, nil
 }
 
+// Pool 返回该服务负责的池配置
+func (c *CompoundService) Pool() *util.PoolConfig {
+	return c.pool
+}
+
+// PriceUpdatedAt 最近一次成功获取市场价格的时间，从未获取时为零值
+func (c *CompoundService) PriceUpdatedAt() time.Time {
+	return unixNanoToTime(c.priceUpdatedAt.Load())
+}
+
+// SetReadOnly 切换只读模式（暂停/恢复发送交易），启动自检失败且 PREFLIGHT_MODE=readonly 时启用
+func (c *CompoundService) SetReadOnly(readOnly bool) {
+	if c.readOnly.Swap(readOnly) != readOnly {
+		publishServiceState(c.bus, c.pool, "compound", readOnly)
+	}
+}
+
+func (c *CompoundService) IsReadOnly() bool {
+	return c.readOnly.Load()
+}
+
+// Job 返回复投任务的调度
+func (c *CompoundService) Job() *scheduler.Job {
+	return c.job
+}
+
+// Contract 返回该池的合约绑定
+func (c *CompoundService) Contract() *MiniAMMContract {
+	return c.contract
+}
+
 // GetMarketPrice 获取市场价格（支持模拟波动）
-func (c *CompoundService) GetMarketPrice(ctx context.Context) (*big.Float, error) {
+func (c *CompoundService) GetMarketPrice(ctx context.Context) (price *big.Float, err error) {
+	ctx, span := startPoolSpan(ctx, "oracle.fetch", c.pool)
+	defer func() {
+		if price != nil {
+			priceF, _ := price.Float64()
+			span.SetAttributes(attribute.Float64("oracle.price", priceF))
+		}
+		tracing.End(span, err)
+	}()
+	logger := logging.FromContext(ctx, c.logger)
+
 	// 如果配置了模拟市场价格，则使用模拟波动值
-	if c.config.SimulatedMarketPrice > 0 {
+	if c.pool.SimulatedMarketPrice > 0 {
+		span.SetAttributes(attribute.String("oracle.source", "simulated"))
 		// 使用正弦波模拟价格波动，周期为 60 秒，幅度为 ±10%
-		basePrice := c.config.SimulatedMarketPrice
+		basePrice := c.pool.SimulatedMarketPrice
 		amplitude := 0.05
 		period := 1800.0
 		t := float64(time.Now().Unix()) / period
 		fluctuation := amplitude * math.Sin(2*math.Pi*t)
-		price := basePrice * (1 + fluctuation)
-		priceFloat := big.NewFloat(price)
-		log.Infof("使用模拟波动市场价格: %s (基准: %f, 波动: %.2f%%)", priceFloat.Text('f', 8), basePrice, fluctuation*100)
+		simulated := basePrice * (1 + fluctuation)
+		priceFloat := big.NewFloat(simulated)
+		c.priceUpdatedAt.Store(time.Now().UnixNano())
+		metrics.OraclePrice.WithLabelValues(c.pool.Chain, c.pool.Name).Set(simulated)
+		logger.Infof("使用模拟波动市场价格: %s (基准: %f, 波动: %.2f%%)", priceFloat.Text('f', 8), basePrice, fluctuation*100)
 		return priceFloat, nil
 	}
 
 	// 否则使用 AMM 内部价格
+	span.SetAttributes(attribute.String("oracle.source", "amm"))
 	reserves, err := c.contract.GetReserves(&bind.CallOpts{Context: ctx})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get reserves: %w", err)
@@ -84,94 +163,195 @@ func (c *CompoundService) GetMarketPrice(ctx context.Context) (*big.Float, error
 
 	reserveA := reserves.Arg0
 	reserveB := reserves.Arg1
+	metrics.PoolReserve.WithLabelValues(c.pool.Chain, c.pool.Name, "A").Set(metrics.ToFloat(reserveA))
+	metrics.PoolReserve.WithLabelValues(c.pool.Chain, c.pool.Name, "B").Set(metrics.ToFloat(reserveB))
 
 	if reserveA.Cmp(big.NewInt(0)) == 0 {
 		return nil, fmt.Errorf("reserveA is zero")
 	}
 
-	price := new(big.Float).Quo(new(big.Float).SetInt(reserveB), new(big.Float).SetInt(reserveA))
+	price = new(big.Float).Quo(new(big.Float).SetInt(reserveB), new(big.Float).SetInt(reserveA))
+	c.priceUpdatedAt.Store(time.Now().UnixNano())
+	priceF, _ := price.Float64()
+	metrics.OraclePrice.WithLabelValues(c.pool.Chain, c.pool.Name).Set(priceF)
 
-	log.Infof("DEX price calculated: %s (reserveB/reserveA)", price.Text('f', 8))
+	logger.Infof("DEX price calculated: %s (reserveB/reserveA)", price.Text('f', 8))
 	return price, nil
 }
 
 func (c *CompoundService) Start(ctx context.Context) {
-	ticker := time.NewTicker(c.config.CompoundInterval)
-	defer ticker.Stop()
+	fires := c.job.Start(ctx)
+	if c.pool.CompoundAutoInterval {
+		go c.autoTune(ctx)
+	}
 
-	log.Info("自动复投服务已启动")
+	c.markStarted()
+	c.logger.Info("自动复投服务已启动")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info("自动复投服务已停止")
+			c.logger.Info("自动复投服务已停止")
 			return
-		case <-ticker.C:
-			if err := c.executeCompound(); err != nil {
-				log.Errorf("执行复投失败: %v", err)
-			}
+		case <-fires:
+			c.tick(ctx)
 		}
 	}
 }
 
-func (c *CompoundService) executeCompound() error {
-	log.Info("检查是否需要复投...")
+// tick 执行一次复投检查，每次 tick 产生一条独立的 trace
+func (c *CompoundService) tick(ctx context.Context) {
+	tickID := logging.NewTickID()
+	ctx = logging.WithFields(ctx, log.Fields{"tick_id": tickID})
+	ctx, span := startPoolSpan(ctx, "compound.tick", c.pool, attribute.String("tick_id", tickID))
+	startedAt := time.Now()
+	publish(ctx, c.bus, c.pool, events.TickStarted{Action: models.ActionTypeCompound, TickID: tickID, Trigger: "schedule"})
+	err := c.executeCompound(ctx)
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Errorf("执行复投失败: %v", err)
+	}
+	publishTickFinished(ctx, c.bus, c.pool, models.ActionTypeCompound, tickID, startedAt, err)
+	c.markTick(err)
+	tracing.End(span, err)
+}
 
-	fees, err := c.contract.GetFees(&bind.CallOpts{})
+func (c *CompoundService) executeCompound(ctx context.Context) error {
+	logger := logging.FromContext(ctx, c.logger)
+	logger.Info("检查是否需要复投...")
+
+	feesCtx, feesSpan := startPoolSpan(ctx, "pool.read_fees", c.pool)
+	fees, err := c.contract.GetFees(&bind.CallOpts{Context: feesCtx})
+	tracing.End(feesSpan, err)
 	if err != nil {
 		return fmt.Errorf("获取手续费失败: %w", err)
 	}
+	c.observeFees(fees.Arg0, fees.Arg1)
 
 	feeA := fees.Arg0
 	feeB := fees.Arg1
 
-	log.Infof("当前累积手续费: feeA=%s, feeB=%s", feeA.String(), feeB.String())
+	logger.Infof("当前累积手续费: feeA=%s, feeB=%s", feeA.String(), feeB.String())
 
-	minAmount := big.NewInt(1e15)
-	if feeA.Cmp(minAmount) < 0 && feeB.Cmp(minAmount) < 0 {
-		log.Info("手续费不足，跳过复投")
+	var econ *compoundEconomics
+	if c.pool.TokenBEthPrice > 0 {
+		// 按市场价格和 tokenB 的 ETH 价格估算手续费价值，不足以覆盖 gas 成本的倍数时跳过
+		econ, err = c.estimateCompound(ctx, feeA, feeB)
+		if err != nil {
+			return fmt.Errorf("估算复投收益失败: %w", err)
+		}
+		logger.Infof("复投收益估算: 手续费价值 %s ETH, 预估 gas 成本 %s ETH (gas %d × %s wei)",
+			weiToEther(econ.FeeValue), weiToEther(econ.GasCost), econ.GasEstimate, econ.GasPrice.String())
+		if !econ.worthIt(c.pool.CompoundProfitMultiple) {
+			logger.Infof("手续费价值未达到 gas 成本的 %.2f 倍，跳过复投", c.pool.CompoundProfitMultiple)
+			publishSkipped(ctx, c.bus, c.pool, models.ActionTypeCompound, events.SkipNotNeeded,
+				fmt.Sprintf("手续费价值 %s ETH 未达到 gas 成本 %s ETH 的 %.2f 倍", weiToEther(econ.FeeValue), weiToEther(econ.GasCost), c.pool.CompoundProfitMultiple))
+			return nil
+		}
+	} else {
+		// 未配置 TOKEN_B_ETH_PRICE 时按固定阈值判断
+		minAmount := big.NewInt(1e15)
+		if feeA.Cmp(minAmount) < 0 && feeB.Cmp(minAmount) < 0 {
+			logger.Info("手续费不足，跳过复投")
+			publishSkipped(ctx, c.bus, c.pool, models.ActionTypeCompound, events.SkipNotNeeded, "手续费不足")
+			return nil
+		}
+	}
+
+	if c.IsReadOnly() {
+		logger.Warn("只读模式，跳过复投交易")
+		publishSkipped(ctx, c.bus, c.pool, models.ActionTypeCompound, events.SkipReadOnly, "")
 		return nil
 	}
 
-	log.Info("开始执行复投...")
+	// 发送前以签名账户身份模拟执行，避免把必然 revert 的交易发上链
+	simCtx, simSpan := startPoolSpan(ctx, "tx.simulate", c.pool, attribute.String("tx.method", "compoundFees"))
+	err = c.contract.SimulateCompoundFees(&bind.CallOpts{Context: simCtx, From: c.txService.GetFromAddress()})
+	tracing.End(simSpan, err)
+	if err != nil {
+		return fmt.Errorf("模拟复投交易失败: %w", err)
+	}
 
-	tx, err := c.txService.ExecuteCompoundFees()
+	prediction, err := c.predictCompound(ctx, feeA, feeB)
+	if err != nil {
+		logger.Warnf("预测复投结果失败: %v", err)
+	} else {
+		logger.Infof("预计复投 A=%s B=%s, 铸造 LP %s, 剩余手续费 A=%s B=%s",
+			prediction.CompoundA, prediction.CompoundB, prediction.Liquidity, prediction.RemainderA, prediction.RemainderB)
+	}
+
+	logger.Info("开始执行复投...")
+
+	tx, err := c.txService.ExecuteCompoundFees(ctx, c.contract)
 	if err != nil {
 		return fmt.Errorf("执行复投交易失败: %w", err)
 	}
 
-	log.Infof("复投交易已发送: %s", tx.Hash().Hex())
+	sentAt := time.Now()
+	ctx = logging.WithFields(ctx, log.Fields{"tx_hash": tx.Hash().Hex(), "nonce": tx.Nonce()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Info("复投交易已发送")
+	publishTxSent(ctx, c.bus, c.pool, models.ActionTypeCompound, tx)
 
-	receipt, err := c.txService.WaitForReceipt(tx.Hash())
+	confirmation, err := c.txService.WaitForReceipt(ctx, tx)
 	if err != nil {
+		publishTxFailed(ctx, c.bus, c.pool, models.ActionTypeCompound, tx, err)
 		return fmt.Errorf("等待交易确认失败: %w", err)
 	}
+	receipt := confirmation.Receipt
 
+	gasCost := receiptGasCost(receipt, tx)
 	status := "failed"
+	var outcome *CompoundOutcome
 	if receipt.Status == 1 {
-		log.Infof("✅ 复投成功! Gas 使用: %d", receipt.GasUsed)
+		logger.Infof("✅ 复投成功! Gas 使用: %d, 实际 gas 成本 %s ETH", receipt.GasUsed, weiToEther(gasCost))
 		status = "success"
-	} else {
-		log.Error("❌ 复投交易失败")
-	}
-
-	// Save to database
-	if c.repo != nil {
-		action := &models.BotAction{
-			Timestamp:  time.Now(),
-			ActionType: models.ActionTypeCompound,
-			AmountA:    feeA.String(),
-			AmountB:    feeB.String(),
-			TxHash:     tx.Hash().Hex(),
-			Status:     status,
-			GasUsed:    receipt.GasUsed,
-		}
-		if err := c.repo.Create(action); err != nil {
-			log.Errorf("保存复投记录到数据库失败: %v", err)
+
+		outcome, err = c.compoundOutcome(ctx, receipt)
+		if err != nil {
+			logger.Warnf("解析复投结果失败: %v", err)
 		} else {
-			log.Infof("✅ 复投记录已保存到数据库 (ID: %d)", action.ID)
+			logger.Infof("实际复投 A=%s B=%s, 铸造 LP %s", outcome.CompoundA, outcome.CompoundB, outcome.Liquidity)
+			if prediction != nil && (prediction.CompoundA.Cmp(outcome.CompoundA) != 0 ||
+				prediction.CompoundB.Cmp(outcome.CompoundB) != 0 || prediction.Liquidity.Cmp(outcome.Liquidity) != 0) {
+				logger.Warn("实际复投结果与预测不一致，读取状态后池内发生了其他交易")
+			}
+		}
+	} else {
+		logger.Error("❌ 复投交易失败")
+	}
+
+	// 操作记录随 TxConfirmed 事件发布，由订阅者保存
+	action := &models.BotAction{
+		Pool:       c.pool.Name,
+		ChainID:    c.txService.Chain().ChainID,
+		Timestamp:  time.Now(),
+		ActionType: models.ActionTypeCompound,
+		AmountA:    feeA.String(),
+		AmountB:    feeB.String(),
+		TxHash:     tx.Hash().Hex(),
+		Status:     status,
+		GasUsed:    receipt.GasUsed,
+		GasCostWei: gasCost.String(),
+	}
+	if receipt.BlockNumber != nil {
+		action.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	if outcome != nil {
+		// 记录实际复投的数量，而不是复投前读到的 feeA/feeB
+		action.AmountA = outcome.CompoundA.String()
+		action.AmountB = outcome.CompoundB.String()
+		action.LPMinted = outcome.Liquidity.String()
+		if outcome.RemainderA != nil {
+			logger.Infof("剩余手续费 A=%s B=%s", outcome.RemainderA, outcome.RemainderB)
+			action.FeeRemainderA = outcome.RemainderA.String()
+			action.FeeRemainderB = outcome.RemainderB.String()
 		}
 	}
+	if econ != nil {
+		action.FeeValueWei = econ.FeeValue.String()
+		action.EstimatedGasCostWei = econ.GasCost.String()
+	}
+	publishTxConfirmed(ctx, c.bus, c.pool, tx, confirmation, sentAt, action)
 
 	return nil
 }
@@ -181,27 +361,43 @@ func (c *CompoundService) GetAccumulatedFees() (*big.Int, *big.Int, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	c.observeFees(fees.Arg0, fees.Arg1)
 	return fees.Arg0, fees.Arg1, nil
 }
 
-func (c *CompoundService) GetReserves() (*big.Int, *big.Int, error) {
-	reserves, err := c.contract.GetReserves(&bind.CallOpts{})
+// GetReserves 读取池储备并更新储备指标
+func (c *CompoundService) GetReserves(ctx context.Context) (*big.Int, *big.Int, error) {
+	ctx, span := startPoolSpan(ctx, "pool.read_reserves", c.pool)
+	reserves, err := c.contract.GetReserves(&bind.CallOpts{Context: ctx})
+	tracing.End(span, err)
 	if err != nil {
 		return nil, nil, err
 	}
+	metrics.PoolReserve.WithLabelValues(c.pool.Chain, c.pool.Name, "A").Set(metrics.ToFloat(reserves.Arg0))
+	metrics.PoolReserve.WithLabelValues(c.pool.Chain, c.pool.Name, "B").Set(metrics.ToFloat(reserves.Arg1))
 	return reserves.Arg0, reserves.Arg1, nil
 }
 
+func (c *CompoundService) observeFees(feeA, feeB *big.Int) {
+	metrics.PoolFee.WithLabelValues(c.pool.Chain, c.pool.Name, "A").Set(metrics.ToFloat(feeA))
+	metrics.PoolFee.WithLabelValues(c.pool.Chain, c.pool.Name, "B").Set(metrics.ToFloat(feeB))
+}
+
+// CalculateOptimalAmounts 与合约 compoundFees 一致：两侧都有手续费时按储备比例取能配对的数量，
+// 只有一侧有手续费时单边复投
 func (c *CompoundService) CalculateOptimalAmounts(feeA, feeB, reserveA, reserveB *big.Int) (*big.Int, *big.Int) {
-	if feeA.Cmp(big.NewInt(0)) == 0 || feeB.Cmp(big.NewInt(0)) == 0 {
+	if feeA.Sign() == 0 || feeB.Sign() == 0 {
+		return new(big.Int).Set(feeA), new(big.Int).Set(feeB)
+	}
+	if reserveA.Sign() == 0 || reserveB.Sign() == 0 {
 		return big.NewInt(0), big.NewInt(0)
 	}
 
 	optimalA := new(big.Int).Mul(feeA, reserveB)
 	optimalA.Div(optimalA, reserveA)
 
-	compoundA := feeA
-	compoundB := feeB
+	compoundA := new(big.Int).Set(feeA)
+	compoundB := new(big.Int).Set(feeB)
 
 	if optimalA.Cmp(feeB) <= 0 {
 		compoundB = optimalA
@@ -213,3 +409,106 @@ func (c *CompoundService) CalculateOptimalAmounts(feeA, feeB, reserveA, reserveB
 
 	return compoundA, compoundB
 }
+
+// CompoundPrediction 按合约逻辑预测的一次复投结果
+type CompoundPrediction struct {
+	CompoundA  *big.Int
+	CompoundB  *big.Int
+	Liquidity  *big.Int
+	RemainderA *big.Int // 复投后留在 feeA 中的部分
+	RemainderB *big.Int
+}
+
+// PredictCompound 预测 compoundFees 实际复投的数量、铸造的 LP 以及剩余的手续费
+func (c *CompoundService) PredictCompound(feeA, feeB, reserveA, reserveB, totalSupply *big.Int) *CompoundPrediction {
+	compoundA, compoundB := c.CalculateOptimalAmounts(feeA, feeB, reserveA, reserveB)
+
+	share := func(amount, reserve *big.Int) *big.Int {
+		if reserve.Sign() == 0 {
+			return big.NewInt(0)
+		}
+		liquidity := new(big.Int).Mul(amount, totalSupply)
+		return liquidity.Div(liquidity, reserve)
+	}
+	var liquidity *big.Int
+	switch {
+	case compoundA.Sign() > 0 && compoundB.Sign() > 0:
+		liquidity = share(compoundA, reserveA)
+		if liquidityB := share(compoundB, reserveB); liquidityB.Cmp(liquidity) < 0 {
+			liquidity = liquidityB
+		}
+	case compoundA.Sign() > 0:
+		liquidity = share(compoundA, reserveA)
+	default:
+		liquidity = share(compoundB, reserveB)
+	}
+
+	return &CompoundPrediction{
+		CompoundA:  compoundA,
+		CompoundB:  compoundB,
+		Liquidity:  liquidity,
+		RemainderA: new(big.Int).Sub(feeA, compoundA),
+		RemainderB: new(big.Int).Sub(feeB, compoundB),
+	}
+}
+
+// predictCompound 读取储备和 LP 总量并预测复投结果
+func (c *CompoundService) predictCompound(ctx context.Context, feeA, feeB *big.Int) (*CompoundPrediction, error) {
+	reserveA, reserveB, err := c.GetReserves(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取储备量失败: %w", err)
+	}
+	totalSupply, err := c.contract.TotalSupply(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("获取 LP 总量失败: %w", err)
+	}
+	return c.PredictCompound(feeA, feeB, reserveA, reserveB, totalSupply), nil
+}
+
+// CompoundOutcome 从交易回执和上链前的状态得到的实际复投结果，无法确定剩余手续费时 Remainder 为 nil
+type CompoundOutcome struct {
+	CompoundA  *big.Int
+	CompoundB  *big.Int
+	Liquidity  *big.Int
+	RemainderA *big.Int
+	RemainderB *big.Int
+}
+
+// compoundOutcome 解码 FeeCollected/Mint 事件，剩余手续费按交易所在区块前一块的 feeA/feeB 减去本次复投量计算。
+// 不读取交易所在区块的状态，因为同一区块中排在复投之后的 swap 会再累积手续费
+func (c *CompoundService) compoundOutcome(ctx context.Context, receipt *types.Receipt) (*CompoundOutcome, error) {
+	collected, err := c.contract.ParseFeeCollected(receipt)
+	if err != nil {
+		return nil, err
+	}
+	mint, err := c.contract.ParseMint(receipt)
+	if err != nil {
+		return nil, err
+	}
+	if collected == nil || mint == nil {
+		return nil, errors.New("交易回执中没有 FeeCollected/Mint 事件")
+	}
+
+	outcome := &CompoundOutcome{
+		CompoundA: collected.FeeA,
+		CompoundB: collected.FeeB,
+		Liquidity: mint.Liquidity,
+	}
+	before := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	fees, err := c.contract.GetFees(&bind.CallOpts{Context: ctx, BlockNumber: before})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Warnf("读取复投前手续费失败，不记录剩余手续费: %v", err)
+		return outcome, nil
+	}
+	remainderA := new(big.Int).Sub(fees.Arg0, collected.FeeA)
+	remainderB := new(big.Int).Sub(fees.Arg1, collected.FeeB)
+	if remainderA.Sign() < 0 || remainderB.Sign() < 0 {
+		// 同一区块中排在复投之前的 swap 也累积了手续费，无法从区块边界的状态还原
+		logging.FromContext(ctx, c.logger).Warnf("复投量超过前一区块的手续费，不记录剩余手续费: feeA=%s, feeB=%s", fees.Arg0, fees.Arg1)
+		return outcome, nil
+	}
+	c.observeFees(remainderA, remainderB)
+	outcome.RemainderA = remainderA
+	outcome.RemainderB = remainderB
+	return outcome, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestPredictCompound 期望值按合约 compoundFees 手算（contracts/contract/MiniAMM.sol）：
+// 两侧都有手续费时按 reserveB/reserveA 取能配对的部分，只有一侧有手续费时单边复投，
+// LP 为各侧 compound*totalSupply/reserve 向下取整后的较小值
+func TestPredictCompound(t *testing.T) {
+	tests := []struct {
+		name                   string
+		feeA, feeB             int64
+		reserveA, reserveB     int64
+		compoundA, compoundB   int64
+		liquidity              int64
+		remainderA, remainderB int64
+	}{
+		{"A 侧受限，B 侧剩余", 10, 30, 1000, 2000, 10, 20, 14, 0, 10},
+		{"B 侧受限，A 侧向下取整", 10, 5, 1000, 2000, 2, 5, 2, 8, 0},
+		{"恰好按储备比例", 3, 6, 1000, 2000, 3, 6, 4, 0, 0},
+		{"只有 A 侧手续费时单边复投", 7, 0, 1000, 2000, 7, 0, 9, 0, 0},
+		{"只有 B 侧手续费时单边复投", 0, 9, 1000, 2000, 0, 9, 6, 0, 0},
+		{"配对后一侧取整为 0", 1, 1, 1000, 2000, 0, 1, 0, 1, 0},
+		{"没有手续费", 0, 0, 1000, 2000, 0, 0, 0, 0, 0},
+		{"储备为 0", 10, 10, 0, 0, 0, 0, 0, 10, 10},
+		{"单边复投时储备为 0", 5, 0, 0, 0, 5, 0, 0, 0, 0},
+	}
+	c := &CompoundService{}
+	totalSupply := big.NewInt(1414)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feeA, feeB := big.NewInt(tt.feeA), big.NewInt(tt.feeB)
+			reserveA, reserveB := big.NewInt(tt.reserveA), big.NewInt(tt.reserveB)
+
+			compoundA, compoundB := c.CalculateOptimalAmounts(feeA, feeB, reserveA, reserveB)
+			if compoundA.Int64() != tt.compoundA || compoundB.Int64() != tt.compoundB {
+				t.Errorf("CalculateOptimalAmounts = (%s, %s), want (%d, %d)", compoundA, compoundB, tt.compoundA, tt.compoundB)
+			}
+
+			prediction := c.PredictCompound(feeA, feeB, reserveA, reserveB, totalSupply)
+			got := []int64{
+				prediction.CompoundA.Int64(), prediction.CompoundB.Int64(), prediction.Liquidity.Int64(),
+				prediction.RemainderA.Int64(), prediction.RemainderB.Int64(),
+			}
+			want := []int64{tt.compoundA, tt.compoundB, tt.liquidity, tt.remainderA, tt.remainderB}
+			for i, name := range []string{"CompoundA", "CompoundB", "Liquidity", "RemainderA", "RemainderB"} {
+				if got[i] != want[i] {
+					t.Errorf("%s = %d, want %d", name, got[i], want[i])
+				}
+			}
+
+			// 不修改传入的手续费
+			if feeA.Int64() != tt.feeA || feeB.Int64() != tt.feeB {
+				t.Errorf("手续费被修改为 (%s, %s)", feeA, feeB)
+			}
+		})
+	}
+}
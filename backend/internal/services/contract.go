@@ -5,24 +5,28 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"go.opentelemetry.io/otel/attribute"
+
+	"mini-amm-bot/internal/tracing"
+	util "mini-amm-bot/internal/util"
 )
 
 type MiniAMMContract struct {
 	address common.Address
-	client  *ethclient.Client
+	rpc     *util.RPCClient
 	abi     abi.ABI
 }
 
-func NewMiniAMMContract(address common.Address, client *ethclient.Client) (*MiniAMMContract, error) {
+func NewMiniAMMContract(address common.Address, rpc *util.RPCClient) (*MiniAMMContract, error) {
 	// MiniAMM ABI (简化版，只包含需要的函数)
-	abiStr := `[{"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint256","name":"","type":"uint256"},{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"getFees","outputs":[{"internalType":"uint256","name":"","type":"uint256"},{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"compoundFees","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"bool","name":"AtoB","type":"bool"}],"name":"rebalance","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"}],"stateMutability":"nonpayable","type":"function"}]`
+	abiStr := `[{"inputs":[],"name":"getReserves","outputs":[{"internalType":"uint256","name":"","type":"uint256"},{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"getFees","outputs":[{"internalType":"uint256","name":"","type":"uint256"},{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"compoundFees","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"internalType":"uint256","name":"amountIn","type":"uint256"},{"internalType":"bool","name":"AtoB","type":"bool"}],"name":"rebalance","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"}],"stateMutability":"nonpayable","type":"function"},{"inputs":[],"name":"bot","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"tokenA","outputs":[{"internalType":"contract IERC20","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"inputs":[],"name":"tokenB","outputs":[{"internalType":"contract IERC20","name":"","type":"address"}],"stateMutability":"view","type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"user","type":"address"},{"indexed":false,"internalType":"uint256","name":"amountIn","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"amountOut","type":"uint256"},{"indexed":false,"internalType":"bool","name":"AtoB","type":"bool"},{"indexed":false,"internalType":"uint256","name":"timestamp","type":"uint256"}],"name":"Swap","type":"event"},{"inputs":[],"name":"totalSupply","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint256","name":"feeA","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"feeB","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"timestamp","type":"uint256"}],"name":"FeeCollected","type":"event"},{"anonymous":false,"inputs":[{"indexed":true,"internalType":"address","name":"provider","type":"address"},{"indexed":false,"internalType":"uint256","name":"amountA","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"amountB","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"liquidity","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"timestamp","type":"uint256"}],"name":"Mint","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"internalType":"uint256","name":"amountIn","type":"uint256"},{"indexed":false,"internalType":"uint256","name":"amountOut","type":"uint256"},{"indexed":false,"internalType":"bool","name":"AtoB","type":"bool"},{"indexed":false,"internalType":"uint256","name":"timestamp","type":"uint256"}],"name":"Rebalance","type":"event"}]`
 	parsedABI, err := abi.JSON(strings.NewReader(abiStr))
 	if err != nil {
 		return nil, err
@@ -30,7 +34,7 @@ func NewMiniAMMContract(address common.Address, client *ethclient.Client) (*Mini
 
 	return &MiniAMMContract{
 		address: address,
-		client:  client,
+		rpc:     rpc,
 		abi:     parsedABI,
 	}, nil
 }
@@ -57,7 +61,7 @@ func (c *MiniAMMContract) GetReserves(opts *bind.CallOpts) (struct {
 		msg.From = opts.From
 	}
 
-	output, err := c.client.CallContract(context.Background(), msg, nil)
+	output, err := c.readCall(opts, msg)
 	if err != nil {
 		return result, fmt.Errorf("failed to call contract: %w", err)
 	}
@@ -102,7 +106,7 @@ func (c *MiniAMMContract) GetFees(opts *bind.CallOpts) (struct {
 		msg.From = opts.From
 	}
 
-	output, err := c.client.CallContract(context.Background(), msg, nil)
+	output, err := c.readCall(opts, msg)
 	if err != nil {
 		return result, err
 	}
@@ -121,30 +125,106 @@ func (c *MiniAMMContract) GetFees(opts *bind.CallOpts) (struct {
 	return result, nil
 }
 
-func (c *MiniAMMContract) CompoundFees(opts *bind.TransactOpts) (*types.Transaction, error) {
-	data, err := c.abi.Pack("compoundFees")
+// Bot 返回合约中被授权调用 compoundFees/rebalance 的地址
+func (c *MiniAMMContract) Bot(opts *bind.CallOpts) (common.Address, error) {
+	return c.callAddress(opts, "bot", true)
+}
+
+func (c *MiniAMMContract) TokenA(opts *bind.CallOpts) (common.Address, error) {
+	return c.callAddress(opts, "tokenA", false)
+}
+
+func (c *MiniAMMContract) TokenB(opts *bind.CallOpts) (common.Address, error) {
+	return c.callAddress(opts, "tokenB", false)
+}
+
+// TotalSupply LP 代币总量
+func (c *MiniAMMContract) TotalSupply(opts *bind.CallOpts) (*big.Int, error) {
+	data, err := c.abi.Pack("totalSupply")
 	if err != nil {
 		return nil, err
 	}
 
-	nonce, err := c.client.PendingNonceAt(context.Background(), opts.From)
+	msg := ethereum.CallMsg{
+		To:   &c.address,
+		Data: data,
+	}
+	if opts != nil {
+		msg.From = opts.From
+	}
+
+	output, err := c.readCall(opts, msg)
 	if err != nil {
 		return nil, err
 	}
 
-	gasPrice, err := c.client.SuggestGasPrice(context.Background())
+	results, err := c.abi.Unpack("totalSupply", output)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to unpack totalSupply: %w", err)
 	}
 
-	tx := types.NewTransaction(nonce, c.address, big.NewInt(0), opts.GasLimit, gasPrice, data)
-	signedTx, err := opts.Signer(opts.From, tx)
+	if len(results) < 1 {
+		return nil, fmt.Errorf("insufficient results: got %d, want 1", len(results))
+	}
+
+	return results[0].(*big.Int), nil
+}
+
+// HasCode 检查合约地址上是否部署了代码
+func (c *MiniAMMContract) HasCode(ctx context.Context) (bool, error) {
+	start := time.Now()
+	conn := c.rpc.Conn()
+	code, err := conn.CodeAt(ctx, c.address, nil)
+	c.rpc.Observe(ctx, conn, "eth_getCode", start, err)
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// callAddress 调用返回 address 的无参 view 函数，critical 为 true 时走多节点一致性读取
+func (c *MiniAMMContract) callAddress(opts *bind.CallOpts, method string, critical bool) (common.Address, error) {
+	data, err := c.abi.Pack(method)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	msg := ethereum.CallMsg{
+		To:   &c.address,
+		Data: data,
+	}
+	if opts != nil {
+		msg.From = opts.From
+	}
+
+	call := c.callContract
+	if critical {
+		call = c.criticalCall
+	}
+	output, err := call(callContext(opts), msg)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	results, err := c.abi.Unpack(method, output)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+
+	if len(results) < 1 {
+		return common.Address{}, fmt.Errorf("insufficient results: got %d, want 1", len(results))
+	}
+
+	return results[0].(common.Address), nil
+}
+
+func (c *MiniAMMContract) CompoundFees(opts *bind.TransactOpts) (*types.Transaction, error) {
+	data, err := c.abi.Pack("compoundFees")
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.client.SendTransaction(context.Background(), signedTx)
-	return signedTx, err
+	return c.transact(opts, data)
 }
 
 func (c *MiniAMMContract) Rebalance(opts *bind.TransactOpts, amount *big.Int, AtoB bool) (*types.Transaction, error) {
@@ -153,22 +233,302 @@ func (c *MiniAMMContract) Rebalance(opts *bind.TransactOpts, amount *big.Int, At
 		return nil, err
 	}
 
-	nonce, err := c.client.PendingNonceAt(context.Background(), opts.From)
+	return c.transact(opts, data)
+}
+
+// SimulateCompoundFees 以签名账户身份 eth_call compoundFees，发送交易前确认不会 revert
+func (c *MiniAMMContract) SimulateCompoundFees(opts *bind.CallOpts) error {
+	data, err := c.abi.Pack("compoundFees")
+	if err != nil {
+		return err
+	}
+
+	msg := ethereum.CallMsg{
+		To:   &c.address,
+		Data: data,
+	}
+	if opts != nil {
+		msg.From = opts.From
+	}
+
+	_, err = c.callContract(callContext(opts), msg)
+	return err
+}
+
+// EstimateCompoundFeesGas 以签名账户身份估算 compoundFees 的 gas 用量
+func (c *MiniAMMContract) EstimateCompoundFeesGas(opts *bind.CallOpts) (uint64, error) {
+	data, err := c.abi.Pack("compoundFees")
+	if err != nil {
+		return 0, err
+	}
+
+	msg := ethereum.CallMsg{
+		To:   &c.address,
+		Data: data,
+	}
+	if opts != nil {
+		msg.From = opts.From
+	}
+
+	ctx := callContext(opts)
+	start := time.Now()
+	conn := c.rpc.Conn()
+	gas, err := conn.EstimateGas(ctx, msg)
+	c.rpc.Observe(ctx, conn, "eth_estimateGas", start, err)
+	return gas, err
+}
+
+// SimulateRebalance 以签名账户身份 eth_call rebalance，返回预计的 amountOut
+func (c *MiniAMMContract) SimulateRebalance(opts *bind.CallOpts, amount *big.Int, AtoB bool) (*big.Int, error) {
+	data, err := c.abi.Pack("rebalance", amount, AtoB)
 	if err != nil {
 		return nil, err
 	}
 
-	gasPrice, err := c.client.SuggestGasPrice(context.Background())
+	msg := ethereum.CallMsg{
+		To:   &c.address,
+		Data: data,
+	}
+	if opts != nil {
+		msg.From = opts.From
+	}
+
+	output, err := c.callContract(callContext(opts), msg)
 	if err != nil {
 		return nil, err
 	}
 
+	results, err := c.abi.Unpack("rebalance", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack rebalance: %w", err)
+	}
+
+	if len(results) < 1 {
+		return nil, fmt.Errorf("insufficient results: got %d, want 1", len(results))
+	}
+
+	return results[0].(*big.Int), nil
+}
+
+// transact 使用 opts 中由 TransactionService 分配的 nonce 和 gas price 签名并广播交易，
+// 未指定时才回退到节点查询
+func (c *MiniAMMContract) transact(opts *bind.TransactOpts, data []byte) (*types.Transaction, error) {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var nonce uint64
+	if opts.Nonce != nil {
+		nonce = opts.Nonce.Uint64()
+	} else {
+		start := time.Now()
+		conn := c.rpc.Conn()
+		pending, err := conn.PendingNonceAt(ctx, opts.From)
+		c.rpc.Observe(ctx, conn, "eth_getTransactionCount", start, err)
+		if err != nil {
+			return nil, err
+		}
+		nonce = pending
+	}
+
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		start := time.Now()
+		conn := c.rpc.Conn()
+		suggested, err := conn.SuggestGasPrice(ctx)
+		c.rpc.Observe(ctx, conn, "eth_gasPrice", start, err)
+		if err != nil {
+			return nil, err
+		}
+		gasPrice = suggested
+	}
+
+	_, signSpan := tracing.Start(ctx, "tx.sign", attribute.Int64("tx.nonce", int64(nonce)))
 	tx := types.NewTransaction(nonce, c.address, big.NewInt(0), opts.GasLimit, gasPrice, data)
 	signedTx, err := opts.Signer(opts.From, tx)
+	tracing.End(signSpan, err)
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.client.SendTransaction(context.Background(), signedTx)
+	broadcastCtx, broadcastSpan := tracing.Start(ctx, "tx.broadcast", attribute.String("tx.hash", signedTx.Hash().Hex()))
+	start := time.Now()
+	conn := c.rpc.Conn()
+	err = conn.SendTransaction(broadcastCtx, signedTx)
+	c.rpc.Observe(broadcastCtx, conn, "eth_sendRawTransaction", start, err)
+	tracing.End(broadcastSpan, err)
 	return signedTx, err
 }
+
+// callContract 在最新区块执行 eth_call 并记录 RPC 指标
+func (c *MiniAMMContract) callContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	return c.callContractAt(ctx, msg, nil)
+}
+
+// callContractAt 在指定区块执行 eth_call，block 为 nil 时为最新区块
+func (c *MiniAMMContract) callContractAt(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	start := time.Now()
+	conn := c.rpc.Conn()
+	output, err := conn.CallContract(ctx, msg, block)
+	c.rpc.Observe(ctx, conn, "eth_call", start, err)
+	return output, err
+}
+
+// readCall 储备、手续费读取：opts 指定了区块时读取该区块的状态（用于交易确认后核对结果），否则走 criticalCall
+func (c *MiniAMMContract) readCall(opts *bind.CallOpts, msg ethereum.CallMsg) ([]byte, error) {
+	if opts != nil && opts.BlockNumber != nil {
+		return c.callContractAt(callContext(opts), msg, opts.BlockNumber)
+	}
+	return c.criticalCall(callContext(opts), msg)
+}
+
+// criticalCall 用于储备、手续费、bot() 等决定是否发送交易的读取，
+// 链配置了 RPC_QUORUM 时要求多个节点在同一区块返回一致的结果
+func (c *MiniAMMContract) criticalCall(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	if !c.rpc.QuorumEnabled() {
+		return c.callContract(ctx, msg)
+	}
+	return c.rpc.QuorumCall(ctx, msg)
+}
+
+// SwapEvent 合约 Swap 事件
+type SwapEvent struct {
+	User        common.Address
+	AmountIn    *big.Int
+	AmountOut   *big.Int
+	AtoB        bool
+	Timestamp   *big.Int
+	BlockNumber uint64
+	TxHash      common.Hash
+}
+
+// SwapTopic Swap 事件的 topic0，用于订阅过滤
+func (c *MiniAMMContract) SwapTopic() common.Hash {
+	return c.abi.Events["Swap"].ID
+}
+
+// ParseSwap 解码 Swap 事件日志
+func (c *MiniAMMContract) ParseSwap(vLog types.Log) (*SwapEvent, error) {
+	if len(vLog.Topics) < 2 || vLog.Topics[0] != c.SwapTopic() {
+		return nil, fmt.Errorf("log is not a Swap event")
+	}
+
+	values, err := c.abi.Unpack("Swap", vLog.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack Swap: %w", err)
+	}
+	if len(values) < 4 {
+		return nil, fmt.Errorf("insufficient Swap fields: got %d, want 4", len(values))
+	}
+
+	return &SwapEvent{
+		User:        common.BytesToAddress(vLog.Topics[1].Bytes()),
+		AmountIn:    values[0].(*big.Int),
+		AmountOut:   values[1].(*big.Int),
+		AtoB:        values[2].(bool),
+		Timestamp:   values[3].(*big.Int),
+		BlockNumber: vLog.BlockNumber,
+		TxHash:      vLog.TxHash,
+	}, nil
+}
+
+// FeeCollectedEvent 合约 FeeCollected 事件，feeA/feeB 为本次实际复投的数量
+type FeeCollectedEvent struct {
+	FeeA      *big.Int
+	FeeB      *big.Int
+	Timestamp *big.Int
+}
+
+// MintEvent 合约 Mint 事件
+type MintEvent struct {
+	Provider  common.Address
+	AmountA   *big.Int
+	AmountB   *big.Int
+	Liquidity *big.Int
+	Timestamp *big.Int
+}
+
+// ParseFeeCollected 在交易回执中查找本合约的 FeeCollected 事件，没有时返回 nil
+func (c *MiniAMMContract) ParseFeeCollected(receipt *types.Receipt) (*FeeCollectedEvent, error) {
+	values, _, err := c.findEvent(receipt, "FeeCollected", 3)
+	if values == nil || err != nil {
+		return nil, err
+	}
+	return &FeeCollectedEvent{
+		FeeA:      values[0].(*big.Int),
+		FeeB:      values[1].(*big.Int),
+		Timestamp: values[2].(*big.Int),
+	}, nil
+}
+
+// ParseMint 在交易回执中查找本合约的 Mint 事件，没有时返回 nil
+func (c *MiniAMMContract) ParseMint(receipt *types.Receipt) (*MintEvent, error) {
+	values, vLog, err := c.findEvent(receipt, "Mint", 4)
+	if values == nil || err != nil {
+		return nil, err
+	}
+	if len(vLog.Topics) < 2 {
+		return nil, fmt.Errorf("missing provider topic in Mint event")
+	}
+	return &MintEvent{
+		Provider:  common.BytesToAddress(vLog.Topics[1].Bytes()),
+		AmountA:   values[0].(*big.Int),
+		AmountB:   values[1].(*big.Int),
+		Liquidity: values[2].(*big.Int),
+		Timestamp: values[3].(*big.Int),
+	}, nil
+}
+
+// RebalanceEvent 合约 Rebalance 事件
+type RebalanceEvent struct {
+	AmountIn  *big.Int
+	AmountOut *big.Int
+	AtoB      bool
+	Timestamp *big.Int
+}
+
+// ParseRebalance 在交易回执中查找本合约的 Rebalance 事件，没有时返回 nil
+func (c *MiniAMMContract) ParseRebalance(receipt *types.Receipt) (*RebalanceEvent, error) {
+	values, _, err := c.findEvent(receipt, "Rebalance", 4)
+	if values == nil || err != nil {
+		return nil, err
+	}
+	return &RebalanceEvent{
+		AmountIn:  values[0].(*big.Int),
+		AmountOut: values[1].(*big.Int),
+		AtoB:      values[2].(bool),
+		Timestamp: values[3].(*big.Int),
+	}, nil
+}
+
+// findEvent 解码回执中第一条由本合约发出的指定事件，返回非 indexed 字段
+func (c *MiniAMMContract) findEvent(receipt *types.Receipt, name string, fields int) ([]interface{}, *types.Log, error) {
+	topic := c.abi.Events[name].ID
+	for _, vLog := range receipt.Logs {
+		if vLog.Address != c.address || len(vLog.Topics) == 0 || vLog.Topics[0] != topic {
+			continue
+		}
+		values, err := c.abi.Unpack(name, vLog.Data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to unpack %s: %w", name, err)
+		}
+		if len(values) < fields {
+			return nil, nil, fmt.Errorf("insufficient %s fields: got %d, want %d", name, len(values), fields)
+		}
+		return values, vLog, nil
+	}
+	return nil, nil, nil
+}
+
+// callContext 取 CallOpts 中的 context，未指定时使用 Background
+func callContext(opts *bind.CallOpts) context.Context {
+	if opts != nil && opts.Context != nil {
+		return opts.Context
+	}
+	return context.Background()
+}
+
+func (c *MiniAMMContract) Address() common.Address {
+	return c.address
+}
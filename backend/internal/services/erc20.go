@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	util "mini-amm-bot/internal/util"
+)
+
+// ERC20Contract 只读的 ERC20 绑定，用于启动自检读取代币精度
+type ERC20Contract struct {
+	address common.Address
+	rpc     *util.RPCClient
+	abi     abi.ABI
+}
+
+func NewERC20Contract(address common.Address, rpc *util.RPCClient) (*ERC20Contract, error) {
+	abiStr := `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+	parsedABI, err := abi.JSON(strings.NewReader(abiStr))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ERC20Contract{
+		address: address,
+		rpc:     rpc,
+		abi:     parsedABI,
+	}, nil
+}
+
+func (c *ERC20Contract) Decimals(ctx context.Context) (uint8, error) {
+	data, err := c.abi.Pack("decimals")
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	conn := c.rpc.Conn()
+	output, err := conn.CallContract(ctx, ethereum.CallMsg{To: &c.address, Data: data}, nil)
+	c.rpc.Observe(ctx, conn, "eth_call", start, err)
+	if err != nil {
+		return 0, err
+	}
+
+	results, err := c.abi.Unpack("decimals", output)
+	if err != nil {
+		return 0, fmt.Errorf("failed to unpack decimals: %w", err)
+	}
+
+	if len(results) < 1 {
+		return 0, fmt.Errorf("insufficient results: got %d, want 1", len(results))
+	}
+
+	return results[0].(uint8), nil
+}
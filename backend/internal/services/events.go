@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/models"
+	util "mini-amm-bot/internal/util"
+)
+
+// publish 发布带池和链信息的事件
+func publish(ctx context.Context, bus *events.Bus, pool *util.PoolConfig, data events.Payload) {
+	bus.Publish(ctx, events.Event{Chain: pool.Chain, Pool: pool.Name, Data: data})
+}
+
+// publishTickFinished 一次检查结束，err 非空时记录错误
+func publishTickFinished(ctx context.Context, bus *events.Bus, pool *util.PoolConfig, action models.ActionType, tickID string, startedAt time.Time, err error) {
+	finished := events.TickFinished{Action: action, TickID: tickID, Duration: time.Since(startedAt).Seconds()}
+	if err != nil {
+		finished.Error = err.Error()
+	}
+	publish(ctx, bus, pool, finished)
+}
+
+// publishSkipped 检查后没有发送交易
+func publishSkipped(ctx context.Context, bus *events.Bus, pool *util.PoolConfig, action models.ActionType, reason events.SkipReason, detail string) {
+	publish(ctx, bus, pool, events.ActionSkipped{Action: action, Reason: reason, Detail: detail})
+}
+
+func publishTxSent(ctx context.Context, bus *events.Bus, pool *util.PoolConfig, action models.ActionType, tx *types.Transaction) {
+	publish(ctx, bus, pool, events.TxSent{Action: action, Hash: tx.Hash().Hex(), Nonce: tx.Nonce()})
+}
+
+// publishTxFailed 等待回执失败：交易被替换或等待超时
+func publishTxFailed(ctx context.Context, bus *events.Bus, pool *util.PoolConfig, action models.ActionType, tx *types.Transaction, err error) {
+	publish(ctx, bus, pool, events.TxFailed{
+		Action:   action,
+		Hash:     tx.Hash().Hex(),
+		Nonce:    tx.Nonce(),
+		Replaced: errors.Is(err, ErrTxReplaced),
+		Error:    err.Error(),
+	})
+}
+
+// publishTxConfirmed 交易已上链，record 为待保存的操作记录
+func publishTxConfirmed(ctx context.Context, bus *events.Bus, pool *util.PoolConfig, tx *types.Transaction, confirmation *Confirmation, sentAt time.Time, record *models.BotAction) {
+	confirmed := events.TxConfirmed{
+		Action:           record.ActionType,
+		Hash:             tx.Hash().Hex(),
+		Nonce:            tx.Nonce(),
+		BlockNumber:      record.BlockNumber,
+		GasUsed:          confirmation.GasUsed,
+		Reverted:         confirmation.Status != types.ReceiptStatusSuccessful,
+		ConfirmSeconds:   time.Since(sentAt).Seconds(),
+		Confirmations:    confirmation.Confirmations,
+		LowConfirmations: confirmation.LowConfirmations,
+		Record:           record,
+	}
+	if confirmation.EffectiveGasPrice != nil {
+		confirmed.GasPriceGwei = metrics.WeiToUnit(confirmation.EffectiveGasPrice, 9)
+	}
+	publish(ctx, bus, pool, confirmed)
+}
+
+// publishServiceState 服务进入或退出只读模式
+func publishServiceState(bus *events.Bus, pool *util.PoolConfig, service string, readOnly bool) {
+	state := events.ServiceResumed
+	if readOnly {
+		state = events.ServicePaused
+	}
+	publish(context.Background(), bus, pool, events.ServiceStateChanged{Service: service, State: state})
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"go.opentelemetry.io/otel/attribute"
+
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/models"
+	"mini-amm-bot/internal/tracing"
+)
+
+// 动态复投间隔的重新计算周期，以及推荐值与当前间隔相差超过该比例才调整
+const (
+	compoundTuneInterval  = time.Hour
+	compoundTuneTolerance = 0.1
+)
+
+// CompoundAssumptions 计算推荐复投间隔使用的输入，金额均按 ETH 计
+type CompoundAssumptions struct {
+	WindowSeconds      int64   `json:"windowSeconds"`
+	SampleCompounds    int     `json:"sampleCompounds"`  // 窗口内成功的复投次数
+	FeeRateEthPerDay   float64 `json:"feeRateEthPerDay"` // 手续费累积速度
+	PoolValueEth       float64 `json:"poolValueEth"`     // 池储备按市场价格折算的总价值
+	FeeAPR             float64 `json:"feeApr"`           // 手续费年化收益率（不含复投）
+	MarketPrice        float64 `json:"marketPrice"`
+	TokenBEthPrice     float64 `json:"tokenBEthPrice"`
+	GasUsed            uint64  `json:"gasUsed"`
+	GasUsedSource      string  `json:"gasUsedSource"` // history（历史平均）或 estimate（eth_estimateGas）
+	GasPriceGwei       float64 `json:"gasPriceGwei"`
+	GasCostEth         float64 `json:"gasCostEth"` // 单次复投 gas 成本
+	ProfitMultiple     float64 `json:"profitMultiple"`
+	MinIntervalSeconds int64   `json:"minIntervalSeconds"`
+	MaxIntervalSeconds int64   `json:"maxIntervalSeconds"`
+}
+
+// CompoundRecommendation 推荐的复投间隔。每次复投花费 gas 成本 C，未复投的手续费不产生收益，
+// 间隔 T 下每秒损失为 r·y·T/2 + C/T（r 为手续费累积速度，y = r/V 为每秒收益率），
+// 最小化得 T* = sqrt(2·C·V) / r；再要求 T 内累积的手续费不少于 COMPOUND_PROFIT_MULTIPLE·C 并限制在上下限内
+type CompoundRecommendation struct {
+	Pool                       string              `json:"pool"`
+	ComputedAt                 time.Time           `json:"computedAt"`
+	OptimalIntervalSeconds     int64               `json:"optimalIntervalSeconds"`
+	RecommendedIntervalSeconds int64               `json:"recommendedIntervalSeconds"`
+	CurrentSchedule            string              `json:"currentSchedule"`
+	AutoInterval               bool                `json:"autoInterval"`
+	DailyCostEthRecommended    float64             `json:"dailyCostEthRecommended"` // 推荐间隔下每天的 gas 成本 + 未复投损失
+	DailyCostEthCurrent        float64             `json:"dailyCostEthCurrent,omitempty"`
+	Assumptions                CompoundAssumptions `json:"assumptions"`
+}
+
+// RecommendInterval 根据历史复投记录、当前手续费、gas 成本和池规模计算推荐的复投间隔
+func (c *CompoundService) RecommendInterval(ctx context.Context) (rec *CompoundRecommendation, err error) {
+	ctx, span := startPoolSpan(ctx, "compound.recommend_interval", c.pool)
+	defer func() {
+		if rec != nil {
+			span.SetAttributes(attribute.Int64("compound.recommended_interval_seconds", rec.RecommendedIntervalSeconds))
+		}
+		tracing.End(span, err)
+	}()
+
+	if c.pool.TokenBEthPrice <= 0 {
+		return nil, errors.New("需要设置 TOKEN_B_ETH_PRICE 才能比较手续费与 gas 成本")
+	}
+	if c.repo == nil {
+		return nil, errors.New("没有数据库，无法读取历史复投记录")
+	}
+
+	now := time.Now()
+	history, err := c.repo.ListSuccessfulSince(ctx, c.pool.Name, models.ActionTypeCompound, now.Add(-c.pool.CompoundHistoryWindow))
+	if err != nil {
+		return nil, fmt.Errorf("读取历史复投记录失败: %w", err)
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("最近 %s 内没有成功的复投记录，无法估算手续费累积速度", c.pool.CompoundHistoryWindow)
+	}
+	// 以第一条记录了剩余手续费的复投为起点，没有剩余手续费的记录无法作为基线
+	for len(history) > 0 && history[0].FeeRemainderA == "" && history[0].FeeRemainderB == "" {
+		history = history[1:]
+	}
+	if len(history) == 0 {
+		return nil, fmt.Errorf("最近 %s 内的复投记录都没有剩余手续费，无法估算手续费累积速度", c.pool.CompoundHistoryWindow)
+	}
+
+	priceF, err := c.GetMarketPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取市场价格失败: %w", err)
+	}
+	price, _ := priceF.Float64()
+	unitB, err := c.tokenBUnit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取 tokenB 精度失败: %w", err)
+	}
+	unit, _ := unitB.Float64()
+	ethPerRawB := c.pool.TokenBEthPrice / unit
+	valueEth := func(amountA, amountB *big.Int) float64 {
+		a, _ := bigFloatFromInt(amountA).Float64()
+		b, _ := bigFloatFromInt(amountB).Float64()
+		return (a*price + b) * ethPerRawB
+	}
+
+	// 手续费累积速度：基线复投之后每次复投的数量加上当前未复投的手续费，减去基线复投后剩余的手续费，
+	// 除以自基线复投以来的时间
+	fees, err := c.contract.GetFees(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("获取手续费失败: %w", err)
+	}
+	parse := func(amount string) *big.Int {
+		value, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return big.NewInt(0)
+		}
+		return value
+	}
+	accrued := valueEth(fees.Arg0, fees.Arg1) - valueEth(parse(history[0].FeeRemainderA), parse(history[0].FeeRemainderB))
+	var gasUsedTotal uint64
+	for i, action := range history {
+		gasUsedTotal += action.GasUsed
+		if i > 0 {
+			accrued += valueEth(parse(action.AmountA), parse(action.AmountB))
+		}
+	}
+	elapsed := now.Sub(history[0].Timestamp).Seconds()
+	if elapsed <= 0 || accrued <= 0 {
+		return nil, errors.New("历史窗口内没有累积手续费")
+	}
+	rate := accrued / elapsed
+
+	reserveA, reserveB, err := c.GetReserves(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取储备量失败: %w", err)
+	}
+	poolValue := valueEth(reserveA, reserveB)
+	if poolValue <= 0 {
+		return nil, errors.New("池储备为 0")
+	}
+
+	// 单次复投 gas 成本：优先使用历史平均 gas 用量，乘以当前 gas price
+	gasPrice, err := c.txService.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gasUsed, gasSource := gasUsedTotal/uint64(len(history)), "history"
+	if gasUsed == 0 {
+		gasUsed, err = c.contract.EstimateCompoundFeesGas(&bind.CallOpts{Context: ctx, From: c.txService.GetFromAddress()})
+		if err != nil {
+			return nil, fmt.Errorf("估算复投 gas 失败: %w", err)
+		}
+		gasSource = "estimate"
+	}
+	gasPriceWei, _ := bigFloatFromInt(gasPrice).Float64()
+	gasCost := float64(gasUsed) * gasPriceWei / 1e18
+
+	yield := rate / poolValue
+	dailyCost := func(interval float64) float64 {
+		return (rate*yield*interval/2 + gasCost/interval) * 86400
+	}
+
+	optimal := math.Sqrt(2*gasCost*poolValue) / rate
+	recommended := math.Max(optimal, c.pool.CompoundProfitMultiple*gasCost/rate)
+	recommended = math.Min(math.Max(recommended, c.pool.CompoundMinInterval.Seconds()), c.pool.CompoundMaxInterval.Seconds())
+
+	rec = &CompoundRecommendation{
+		Pool:                       c.pool.Name,
+		ComputedAt:                 now,
+		OptimalIntervalSeconds:     int64(optimal),
+		RecommendedIntervalSeconds: int64(recommended),
+		CurrentSchedule:            c.job.Spec().String(),
+		AutoInterval:               c.pool.CompoundAutoInterval,
+		DailyCostEthRecommended:    dailyCost(recommended),
+		Assumptions: CompoundAssumptions{
+			WindowSeconds:      int64(c.pool.CompoundHistoryWindow.Seconds()),
+			SampleCompounds:    len(history),
+			FeeRateEthPerDay:   rate * 86400,
+			PoolValueEth:       poolValue,
+			FeeAPR:             yield * 365 * 86400,
+			MarketPrice:        price,
+			TokenBEthPrice:     c.pool.TokenBEthPrice,
+			GasUsed:            gasUsed,
+			GasUsedSource:      gasSource,
+			GasPriceGwei:       gasPriceWei / 1e9,
+			GasCostEth:         gasCost,
+			ProfitMultiple:     c.pool.CompoundProfitMultiple,
+			MinIntervalSeconds: int64(c.pool.CompoundMinInterval.Seconds()),
+			MaxIntervalSeconds: int64(c.pool.CompoundMaxInterval.Seconds()),
+		},
+	}
+	if spec := c.job.Spec(); spec.Interval > 0 {
+		rec.DailyCostEthCurrent = dailyCost(spec.Interval.Seconds())
+	}
+	return rec, nil
+}
+
+// autoTune 定期重新计算推荐间隔，与当前间隔相差超过 compoundTuneTolerance 时调整复投任务的间隔
+func (c *CompoundService) autoTune(ctx context.Context) {
+	ticker := time.NewTicker(compoundTuneInterval)
+	defer ticker.Stop()
+
+	for {
+		c.tuneInterval(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *CompoundService) tuneInterval(ctx context.Context) {
+	logger := logging.FromContext(ctx, c.logger)
+
+	rec, err := c.RecommendInterval(ctx)
+	if err != nil {
+		logger.Warnf("计算推荐复投间隔失败，保持当前间隔 %s: %v", c.job.Spec(), err)
+		return
+	}
+
+	current := c.job.Spec().Interval
+	recommended := time.Duration(rec.RecommendedIntervalSeconds) * time.Second
+	if math.Abs(recommended.Seconds()-current.Seconds()) <= current.Seconds()*compoundTuneTolerance {
+		logger.Debugf("推荐复投间隔 %s 与当前间隔 %s 接近，不调整", recommended, current)
+		return
+	}
+	if err := c.job.SetInterval(recommended); err != nil {
+		logger.Errorf("调整复投间隔失败: %v", err)
+		return
+	}
+	logger.Infof("复投间隔已调整: %s -> %s (手续费 %.6f ETH/天, 单次 gas 成本 %.6f ETH, 池价值 %.4f ETH)",
+		current, recommended, rec.Assumptions.FeeRateEthPerDay, rec.Assumptions.GasCostEth, rec.Assumptions.PoolValueEth)
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	util "mini-amm-bot/internal/util"
+)
+
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// CheckResult 单项启动自检结果，链级检查的 Pool 为空
+type CheckResult struct {
+	Name    string      `json:"name"`
+	Chain   string      `json:"chain"`
+	Pool    string      `json:"pool,omitempty"`
+	Status  CheckStatus `json:"status"`
+	Message string      `json:"message"`
+}
+
+// PreflightReport 启动自检汇总，通过 /health 对外暴露
+type PreflightReport struct {
+	Mode          string        `json:"mode"`
+	CheckedAt     time.Time     `json:"checkedAt"`
+	Results       []CheckResult `json:"results"`
+	ReadOnlyPools []string      `json:"readOnlyPools"`
+}
+
+// Passed 所有检查均未失败（警告不算失败）
+func (r *PreflightReport) Passed() bool {
+	for _, result := range r.Results {
+		if result.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+// PoolFailed 判断某个池是否有失败项，所属链的失败同样计入
+func (r *PreflightReport) PoolFailed(pool *util.PoolConfig) bool {
+	for _, result := range r.Results {
+		if result.Status != CheckFail || result.Chain != pool.Chain {
+			continue
+		}
+		if result.Pool == "" || result.Pool == pool.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Failures 返回所有失败项
+func (r *PreflightReport) Failures() []CheckResult {
+	failures := []CheckResult{}
+	for _, result := range r.Results {
+		if result.Status == CheckFail {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// RunPreflight 对每条链和每个池执行启动自检：
+// 链级检查 RPC 返回的 Chain ID 与配置一致、签名账户余额不低于 MinBalance；
+// 池级检查合约代码存在、代币地址与部署记录一致、代币精度可读、bot() 等于签名账户。
+func RunPreflight(config *util.Config, txServices map[string]*TransactionService, compoundServices []*CompoundService) *PreflightReport {
+	report := &PreflightReport{
+		Mode:          config.PreflightMode,
+		CheckedAt:     time.Now(),
+		Results:       []CheckResult{},
+		ReadOnlyPools: []string{},
+	}
+
+	for _, chain := range config.Chains {
+		if txService, ok := txServices[chain.Name]; ok {
+			report.Results = append(report.Results, checkChain(txService)...)
+		}
+	}
+
+	for _, compoundService := range compoundServices {
+		report.Results = append(report.Results, checkPool(compoundService)...)
+	}
+
+	return report
+}
+
+func checkChain(txService *TransactionService) []CheckResult {
+	chain := txService.Chain()
+	result := func(name string, status CheckStatus, format string, args ...interface{}) CheckResult {
+		return CheckResult{Name: name, Chain: chain.Name, Status: status, Message: fmt.Sprintf(format, args...)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results := []CheckResult{}
+
+	rpcChainID, err := txService.rpcClient.GetClient().ChainID(ctx)
+	switch {
+	case err != nil:
+		results = append(results, result("chain_id", CheckFail, "查询 RPC Chain ID 失败: %v", err))
+	case rpcChainID.Int64() != chain.ChainID:
+		results = append(results, result("chain_id", CheckFail, "RPC Chain ID 为 %d，与配置 %d 不一致", rpcChainID.Int64(), chain.ChainID))
+	default:
+		results = append(results, result("chain_id", CheckPass, "Chain ID %d", chain.ChainID))
+	}
+
+	balance, err := txService.GetBalance()
+	switch {
+	case err != nil:
+		results = append(results, result("signer_balance", CheckFail, "查询签名账户余额失败: %v", err))
+	case chain.MinBalance != nil && balance.Cmp(chain.MinBalance) < 0:
+		results = append(results, result("signer_balance", CheckFail, "签名账户 %s 余额 %s wei 低于最低要求 %s wei",
+			txService.GetFromAddress().Hex(), balance.String(), chain.MinBalance.String()))
+	default:
+		results = append(results, result("signer_balance", CheckPass, "签名账户 %s 余额 %s wei", txService.GetFromAddress().Hex(), balance.String()))
+	}
+
+	return results
+}
+
+func checkPool(compoundService *CompoundService) []CheckResult {
+	pool := compoundService.Pool()
+	contract := compoundService.Contract()
+	txService := compoundService.txService
+	result := func(name string, status CheckStatus, format string, args ...interface{}) CheckResult {
+		return CheckResult{Name: name, Chain: pool.Chain, Pool: pool.Name, Status: status, Message: fmt.Sprintf(format, args...)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hasCode, err := contract.HasCode(ctx)
+	if err != nil {
+		return []CheckResult{result("contract_code", CheckFail, "查询合约代码失败: %v", err)}
+	}
+	if !hasCode {
+		// 没有代码时后续调用都会失败，直接返回
+		return []CheckResult{result("contract_code", CheckFail, "地址 %s 上没有合约代码", contract.Address().Hex())}
+	}
+	results := []CheckResult{result("contract_code", CheckPass, "合约 %s", contract.Address().Hex())}
+
+	opts := &bind.CallOpts{Context: ctx}
+
+	tokens := []struct {
+		name     string
+		expected string
+		fetch    func(*bind.CallOpts) (common.Address, error)
+	}{
+		{"tokenA", pool.TokenAAddress, contract.TokenA},
+		{"tokenB", pool.TokenBAddress, contract.TokenB},
+	}
+	decimals := make([]uint8, 0, len(tokens))
+	for _, token := range tokens {
+		actual, err := token.fetch(opts)
+		if err != nil {
+			results = append(results, result("token_address", CheckFail, "查询 %s 失败: %v", token.name, err))
+			continue
+		}
+		if token.expected != "" && !strings.EqualFold(actual.Hex(), token.expected) {
+			results = append(results, result("token_address", CheckFail, "合约 %s 为 %s，与部署记录 %s 不一致", token.name, actual.Hex(), token.expected))
+			continue
+		}
+
+		erc20, err := NewERC20Contract(actual, txService.rpcClient)
+		if err != nil {
+			results = append(results, result("token_decimals", CheckFail, "创建 %s 绑定失败: %v", token.name, err))
+			continue
+		}
+		d, err := erc20.Decimals(ctx)
+		if err != nil {
+			results = append(results, result("token_decimals", CheckFail, "读取 %s (%s) decimals 失败: %v", token.name, actual.Hex(), err))
+			continue
+		}
+		decimals = append(decimals, d)
+	}
+	if len(decimals) == len(tokens) {
+		// 再平衡按储备原始数量计价，两侧精度不同会导致价值计算偏差
+		if decimals[0] != decimals[1] {
+			results = append(results, result("token_decimals", CheckWarn, "tokenA decimals=%d 与 tokenB decimals=%d 不同，价格需按精度换算", decimals[0], decimals[1]))
+		} else {
+			results = append(results, result("token_decimals", CheckPass, "decimals=%d", decimals[0]))
+		}
+	}
+
+	bot, err := contract.Bot(opts)
+	switch {
+	case err != nil:
+		results = append(results, result("bot_address", CheckFail, "查询 bot() 失败: %v", err))
+	case bot != txService.GetFromAddress():
+		results = append(results, result("bot_address", CheckFail, "合约 bot() 为 %s，与签名账户 %s 不一致", bot.Hex(), txService.GetFromAddress().Hex()))
+	default:
+		results = append(results, result("bot_address", CheckPass, "bot() = %s", bot.Hex()))
+	}
+
+	return results
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/attribute"
+
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/tracing"
+)
+
+// compoundEconomics 一次复投的收益估算，金额均以 wei 计
+type compoundEconomics struct {
+	FeeValue    *big.Int // feeA 按市场价格折算为 tokenB，再按 TOKEN_B_ETH_PRICE 折算为 ETH
+	GasEstimate uint64
+	GasPrice    *big.Int
+	GasCost     *big.Int // GasEstimate × GasPrice
+}
+
+// worthIt 手续费价值是否达到 gas 成本的 multiple 倍
+func (e *compoundEconomics) worthIt(multiple float64) bool {
+	threshold := new(big.Float).Mul(bigFloatFromInt(e.GasCost), big.NewFloat(multiple))
+	return bigFloatFromInt(e.FeeValue).Cmp(threshold) >= 0
+}
+
+// estimateCompound 估算复投的手续费价值与 gas 成本
+func (c *CompoundService) estimateCompound(ctx context.Context, feeA, feeB *big.Int) (econ *compoundEconomics, err error) {
+	ctx, span := startPoolSpan(ctx, "compound.estimate", c.pool)
+	defer func() {
+		if econ != nil {
+			span.SetAttributes(
+				attribute.String("compound.fee_value_wei", econ.FeeValue.String()),
+				attribute.String("compound.gas_cost_wei", econ.GasCost.String()),
+				attribute.Int64("compound.gas_estimate", int64(econ.GasEstimate)),
+			)
+		}
+		tracing.End(span, err)
+	}()
+	logger := logging.FromContext(ctx, c.logger)
+
+	price, err := c.GetMarketPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取市场价格失败: %w", err)
+	}
+	unitB, err := c.tokenBUnit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取 tokenB 精度失败: %w", err)
+	}
+
+	// feeA × price + feeB 为以 tokenB 最小单位计的价值，除以 10^decimals 得到 tokenB 数量，再换算为 ETH wei
+	value := new(big.Float).Mul(bigFloatFromInt(feeA), price)
+	value.Add(value, bigFloatFromInt(feeB))
+	value.Quo(value, unitB)
+	value.Mul(value, big.NewFloat(c.pool.TokenBEthPrice))
+	value.Mul(value, big.NewFloat(1e18))
+
+	gasPrice, err := c.txService.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gas, err := c.contract.EstimateCompoundFeesGas(&bind.CallOpts{Context: ctx, From: c.txService.GetFromAddress()})
+	if err != nil {
+		// 估算失败（例如只读模式下签名账户不是 bot）时按交易 gas 上限保守估计
+		gas = c.txService.Chain().GasLimit
+		logger.Warnf("估算复投 gas 失败，按 gas 上限 %d 计算: %v", gas, err)
+	}
+
+	econ = &compoundEconomics{
+		FeeValue:    floatToBigIntFloor(value),
+		GasEstimate: gas,
+		GasPrice:    gasPrice,
+		GasCost:     new(big.Int).Mul(new(big.Int).SetUint64(gas), gasPrice),
+	}
+	metrics.CompoundEconomics.WithLabelValues(c.pool.Chain, c.pool.Name, "fee_value").Set(metrics.WeiToUnit(econ.FeeValue, 18))
+	metrics.CompoundEconomics.WithLabelValues(c.pool.Chain, c.pool.Name, "gas_cost").Set(metrics.WeiToUnit(econ.GasCost, 18))
+	return econ, nil
+}
+
+// tokenBUnit 10^decimals(tokenB)，首次调用时从链上读取并缓存
+func (c *CompoundService) tokenBUnit(ctx context.Context) (*big.Float, error) {
+	c.tokenBMu.Lock()
+	defer c.tokenBMu.Unlock()
+
+	if c.tokenBDecimals == nil {
+		tokenB, err := c.contract.TokenB(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return nil, err
+		}
+		erc20, err := NewERC20Contract(tokenB, c.rpcClient)
+		if err != nil {
+			return nil, err
+		}
+		decimals, err := erc20.Decimals(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.tokenBDecimals = &decimals
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(*c.tokenBDecimals)), nil)
+	return new(big.Float).SetInt(unit), nil
+}
+
+// receiptGasCost 交易实际 gas 成本（gasUsed × effectiveGasPrice），节点未返回有效价格时回退到交易的 gas price
+func receiptGasCost(receipt *types.Receipt, tx *types.Transaction) *big.Int {
+	price := receipt.EffectiveGasPrice
+	if price == nil || price.Sign() == 0 {
+		price = tx.GasPrice()
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), price)
+}
+
+// weiToEther 日志中展示的 ETH 数量
+func weiToEther(wei *big.Int) string {
+	return new(big.Float).Quo(bigFloatFromInt(wei), big.NewFloat(1e18)).Text('f', 8)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"mini-amm-bot/internal/health"
+	util "mini-amm-bot/internal/util"
+)
+
+// staleTickFactor 超过该倍数的执行间隔仍没有成功执行，视为服务卡住
+const staleTickFactor = 3
+
+// ChainReadinessChecks 链级就绪检查：RPC 最新区块时间与当前时间的差距、签名账户余额
+func ChainReadinessChecks(txService *TransactionService) []health.Check {
+	chain := txService.Chain()
+
+	return []health.Check{
+		{
+			Name: "rpc:" + chain.Name,
+			Run: func(ctx context.Context) health.Component {
+				start := time.Now()
+				conn := txService.rpcClient.Conn()
+				header, err := conn.HeaderByNumber(ctx, nil)
+				txService.rpcClient.Observe(ctx, conn, "eth_getBlockByNumber", start, err)
+				if err != nil {
+					return health.Unhealthy(fmt.Sprintf("获取最新区块失败: %v", err), nil)
+				}
+				blockTime := time.Unix(int64(header.Time), 0)
+				lag := time.Since(blockTime)
+				details := map[string]interface{}{
+					"blockNumber":   header.Number.Uint64(),
+					"blockTime":     blockTime,
+					"lagSeconds":    int64(lag.Seconds()),
+					"maxLagSeconds": int64(chain.MaxBlockAge.Seconds()),
+					"chainId":       chain.ChainID,
+					"rpcEndpoint":   txService.rpcClient.CurrentEndpoint(),
+					"endpoints":     txService.rpcClient.Endpoints(),
+				}
+				if chain.MaxBlockAge > 0 && lag > chain.MaxBlockAge {
+					return health.Unhealthy(fmt.Sprintf("最新区块落后 %s，超过 %s", lag.Round(time.Second), chain.MaxBlockAge), details)
+				}
+				return health.OK(details)
+			},
+		},
+		{
+			Name: "signer_balance:" + chain.Name,
+			Run: func(ctx context.Context) health.Component {
+				balance, err := txService.balanceAt(ctx)
+				if err != nil {
+					return health.Unhealthy(fmt.Sprintf("查询签名账户余额失败: %v", err), nil)
+				}
+				details := map[string]interface{}{
+					"address":    txService.GetFromAddress().Hex(),
+					"balanceWei": balance.String(),
+				}
+				if chain.MinBalance != nil {
+					details["minBalanceWei"] = chain.MinBalance.String()
+					if balance.Cmp(chain.MinBalance) < 0 {
+						return health.Unhealthy("签名账户余额低于最低要求", details)
+					}
+				}
+				return health.OK(details)
+			},
+		},
+	}
+}
+
+// PoolReadinessChecks 池级就绪检查：复投/再平衡服务最近一次成功执行时间、市场价格新鲜度
+func PoolReadinessChecks(compoundService *CompoundService, rebalanceService *RebalanceService) []health.Check {
+	pool := compoundService.Pool()
+
+	return []health.Check{
+		{
+			Name: "compound:" + pool.Name,
+			Run: func(ctx context.Context) health.Component {
+				return tickComponent(&compoundService.tickTracker, compoundService.Job().Period(pool.CompoundInterval))
+			},
+		},
+		{
+			Name: "rebalance:" + pool.Name,
+			Run: func(ctx context.Context) health.Component {
+				component := tickComponent(&rebalanceService.tickTracker, rebalanceService.Job().Period(pool.RebalanceInterval))
+				if pool.RebalanceMode != util.RebalanceModeEvent {
+					return component
+				}
+				// 事件驱动模式下没有 swap 时不会触发检查，订阅正常即视为就绪
+				component.Details["mode"] = pool.RebalanceMode
+				component.Details["subscribed"] = rebalanceService.IsSubscribed()
+				if rebalanceService.IsSubscribed() && !rebalanceService.StartedAt().IsZero() {
+					return health.OK(component.Details)
+				}
+				return component
+			},
+		},
+		{
+			Name: "oracle:" + pool.Name,
+			Run: func(ctx context.Context) health.Component {
+				updatedAt := compoundService.PriceUpdatedAt()
+				details := map[string]interface{}{
+					"maxAgeSeconds": int64(pool.OracleMaxAge.Seconds()),
+				}
+				// 尚未获取过价格时，从再平衡服务启动时刻开始计算
+				since := updatedAt
+				if since.IsZero() {
+					since = rebalanceService.StartedAt()
+				} else {
+					details["updatedAt"] = updatedAt
+				}
+				if since.IsZero() || pool.OracleMaxAge <= 0 {
+					return health.OK(details)
+				}
+				age := time.Since(since)
+				details["ageSeconds"] = int64(age.Seconds())
+				if age > pool.OracleMaxAge {
+					return health.Unhealthy(fmt.Sprintf("市场价格已 %s 未更新", age.Round(time.Second)), details)
+				}
+				return health.OK(details)
+			},
+		},
+	}
+}
+
+func tickComponent(tracker *tickTracker, interval time.Duration) health.Component {
+	startedAt := tracker.StartedAt()
+	lastTick := tracker.LastTickAt()
+	lastSuccess := tracker.LastSuccessAt()
+
+	details := map[string]interface{}{
+		"intervalSeconds": int64(interval.Seconds()),
+	}
+	if !lastTick.IsZero() {
+		details["lastTickAt"] = lastTick
+	}
+	if !lastSuccess.IsZero() {
+		details["lastSuccessAt"] = lastSuccess
+	}
+
+	if startedAt.IsZero() {
+		return health.Unhealthy("服务未启动", details)
+	}
+
+	since := lastSuccess
+	if since.IsZero() {
+		since = startedAt
+	}
+	if maxAge := staleTickFactor * interval; time.Since(since) > maxAge {
+		return health.Unhealthy(fmt.Sprintf("超过 %s 没有成功执行", maxAge), details)
+	}
+	return health.OK(details)
+}
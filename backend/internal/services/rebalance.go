@@ -6,82 +6,148 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"sync/atomic"
 	"time"
 
-	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
 	"mini-amm-bot/internal/models"
+	"mini-amm-bot/internal/scheduler"
+	"mini-amm-bot/internal/tracing"
 	util "mini-amm-bot/internal/util"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // 市场决定版本的 RebalanceService：
 // - 不维护 initialPrice（不追逐价格）
 // - 使用外部 marketPrice (oracle 或市场聚合价) 作为定价依据
 // - 目标策略：按市值 50/50 分配（可扩展为可配置目标比例）
-// - 再平衡采用温和策略：限制单次交易量、检测最小交易量、记录日志并保存到 repo
+// - 再平衡采用温和策略：限制单次交易量、检测最小交易量、记录日志并通过事件总线发布结果
 type RebalanceService struct {
 	config          *util.Config
+	pool            *util.PoolConfig
 	rpcClient       *util.RPCClient
 	txService       *TransactionService
 	compoundService *CompoundService
-	repo            *db.BotActionRepository
+	bus             *events.Bus
+	job             *scheduler.Job
+	logger          *log.Entry
+	readOnly        atomic.Bool // 只读模式下只做检查不发送交易
+	tickTracker
+
+	// 事件驱动模式：Swap 订阅是否正常、最近一次检查读到的储备（用于判断大额 swap）
+	subscribed atomic.Bool
+	reserves   atomic.Pointer[[2]*big.Int]
 
 	// 可配置的目标价值比例 (默认 0.5 即 50/50)
 	targetValueShare float64
 }
 
-func NewRebalanceServiceMarket(config *util.Config, rpcClient *util.RPCClient, txService *TransactionService, compoundService *CompoundService, repo *db.BotActionRepository) (*RebalanceService, error) {
-	// targetValueShare 可从 config 获取，默认 0.5
+func NewRebalanceServiceMarket(config *util.Config, rpcClient *util.RPCClient, txService *TransactionService, compoundService *CompoundService, bus *events.Bus, sched *scheduler.Scheduler) (*RebalanceService, error) {
+	// 与复投服务共用同一个池配置
+	pool := compoundService.Pool()
+
+	job, err := addJob(sched, pool, "rebalance", pool.RebalanceSchedule, pool.RebalanceInterval, rpcClient)
+	if err != nil {
+		return nil, err
+	}
+
+	// targetValueShare 可从池配置获取，默认 0.5
 	target := 0.5
-	if config != nil && config.TargetValueShare > 0 {
-		target = config.TargetValueShare
+	if pool.TargetValueShare > 0 {
+		target = pool.TargetValueShare
 	}
 	return &RebalanceService{
 		config:           config,
+		pool:             pool,
 		rpcClient:        rpcClient,
 		txService:        txService,
 		compoundService:  compoundService,
-		repo:             repo,
+		bus:              bus,
+		job:              job,
+		logger:           logging.Component("rebalance").WithFields(log.Fields{"pool": pool.Name, "chain": pool.Chain}),
 		targetValueShare: target,
 	}, nil
 }
 
+// SetReadOnly 切换只读模式，启动自检失败且 PREFLIGHT_MODE=readonly 时启用
+func (r *RebalanceService) SetReadOnly(readOnly bool) {
+	if r.readOnly.Swap(readOnly) != readOnly {
+		publishServiceState(r.bus, r.pool, "rebalance", readOnly)
+	}
+}
+
+func (r *RebalanceService) IsReadOnly() bool {
+	return r.readOnly.Load()
+}
+
+// Job 返回再平衡任务的调度；事件驱动模式下为订阅断开时的回退检查
+func (r *RebalanceService) Job() *scheduler.Job {
+	return r.job
+}
+
 func (r *RebalanceService) Start(ctx context.Context) {
-	ticker := time.NewTicker(r.config.RebalanceInterval)
-	defer ticker.Stop()
+	if r.pool.RebalanceMode == util.RebalanceModeEvent {
+		r.startEventDriven(ctx)
+		return
+	}
 
-	log.Info("自动再平衡服务已启动")
+	fires := r.job.Start(ctx)
+
+	r.markStarted()
+	r.logger.Info("自动再平衡服务已启动")
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Info("自动再平衡服务已停止")
+			r.logger.Info("自动再平衡服务已停止")
 			return
-		case <-ticker.C:
-			if err := r.checkAndRebalanceMarket(); err != nil {
-				log.Errorf("再平衡检查失败: %v", err)
-			}
+		case <-fires:
+			r.tick(ctx, "schedule")
 		}
 	}
 }
 
+// tick 执行一次再平衡检查，每次 tick 产生一条独立的 trace；trigger 为 schedule（按调度规则）或 swap（Swap 事件）
+func (r *RebalanceService) tick(ctx context.Context, trigger string) {
+	tickID := logging.NewTickID()
+	ctx = logging.WithFields(ctx, log.Fields{"tick_id": tickID, "trigger": trigger})
+	ctx, span := startPoolSpan(ctx, "rebalance.tick", r.pool, attribute.String("tick_id", tickID), attribute.String("trigger", trigger))
+	startedAt := time.Now()
+	publish(ctx, r.bus, r.pool, events.TickStarted{Action: models.ActionTypeRebalance, TickID: tickID, Trigger: trigger})
+	err := r.checkAndRebalanceMarket(ctx)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Errorf("再平衡检查失败: %v", err)
+	}
+	publishTickFinished(ctx, r.bus, r.pool, models.ActionTypeRebalance, tickID, startedAt, err)
+	r.markTick(err)
+	tracing.End(span, err)
+}
+
 // checkAndRebalanceMarket
-func (r *RebalanceService) checkAndRebalanceMarket() error {
-	log.Info("执行再平衡检查")
+func (r *RebalanceService) checkAndRebalanceMarket(ctx context.Context) error {
+	logger := logging.FromContext(ctx, r.logger)
+	logger.Info("执行再平衡检查")
 
 	// 1. 获取储备
-	reserveA, reserveB, err := r.compoundService.GetReserves()
+	reserveA, reserveB, err := r.compoundService.GetReserves(ctx)
 	if err != nil {
 		return fmt.Errorf("获取储备量失败: %w", err)
 	}
 	if reserveA.Cmp(big.NewInt(0)) == 0 || reserveB.Cmp(big.NewInt(0)) == 0 {
 		return errors.New("流动性池储备不足，跳过")
 	}
+	r.reserves.Store(&[2]*big.Int{reserveA, reserveB})
 
 	// 2. 获取市场价格
-	marketPrice, err := r.compoundService.GetMarketPrice(context.Background())
+	marketPrice, err := r.compoundService.GetMarketPrice(ctx)
 	if err != nil {
+		r.checkOracleStale(ctx, err)
 		return fmt.Errorf("获取市场价格失败: %w", err)
 	}
 
@@ -112,21 +178,26 @@ func (r *RebalanceService) checkAndRebalanceMarket() error {
 	targetReserveAInt := floatToBigIntFloor(targetReserveA)
 	targetReserveBInt := floatToBigIntFloor(targetReserveB)
 
-	log.Infof("当前储备: A=%s, B=%s", reserveA.String(), reserveB.String())
-	log.Infof("目标储备: A=%s, B=%s", targetReserveAInt.String(), targetReserveBInt.String())
+	logger.Infof("当前储备: A=%s, B=%s", reserveA.String(), reserveB.String())
+	logger.Infof("目标储备: A=%s, B=%s", targetReserveAInt.String(), targetReserveBInt.String())
 
 	// 检查目标储备是否过大（防止合约溢出）
 	maxReserve := new(big.Int).Lsh(big.NewInt(1), 255) // 2^255，大约 5.7e76
 	if targetReserveAInt.Cmp(maxReserve) > 0 || targetReserveBInt.Cmp(maxReserve) > 0 {
-		log.Warnf("目标储备过大，跳过再平衡: targetA=%s, targetB=%s", targetReserveAInt.String(), targetReserveBInt.String())
+		logger.Warnf("目标储备过大，跳过再平衡: targetA=%s, targetB=%s", targetReserveAInt.String(), targetReserveBInt.String())
+		publishSkipped(ctx, r.bus, r.pool, models.ActionTypeRebalance, events.SkipNotNeeded, "目标储备过大")
 		return nil
 	}
 
 	// 5. 偏差判断
 	diffValue := new(big.Float).Abs(new(big.Float).Sub(valueA, valueB))
 	deviationFloat, _ := new(big.Float).Quo(diffValue, totalValue).Float64()
+	metrics.OracleDeviation.WithLabelValues(r.pool.Chain, r.pool.Name).Set(deviationFloat)
+	r.publishPoolState(ctx, reserveA, reserveB, marketPrice, deviationFloat)
 
-	if deviationFloat <= r.config.RebalanceThreshold {
+	if deviationFloat <= r.pool.RebalanceThreshold {
+		publishSkipped(ctx, r.bus, r.pool, models.ActionTypeRebalance, events.SkipNotNeeded,
+			fmt.Sprintf("偏差 %.4f 未超过阈值 %.4f", deviationFloat, r.pool.RebalanceThreshold))
 		return nil // 不需要 rebalance
 	}
 
@@ -146,11 +217,12 @@ func (r *RebalanceService) checkAndRebalanceMarket() error {
 		directionAtoB = false
 		swapAmount = diffB
 	} else {
+		publishSkipped(ctx, r.bus, r.pool, models.ActionTypeRebalance, events.SkipNotNeeded, "储备已达到目标")
 		return nil
 	}
 
 	// 7. 限制换手比例
-	maxFrac := r.config.MaxRebalanceFraction
+	maxFrac := r.pool.MaxRebalanceFraction
 	if maxFrac <= 0 || maxFrac > 1 {
 		maxFrac = 0.1
 	}
@@ -161,7 +233,7 @@ func (r *RebalanceService) checkAndRebalanceMarket() error {
 	}
 
 	// // 8. 最小换手量过滤
-	// minSwap := r.config.MinRebalanceAmount
+	// minSwap := r.pool.MinRebalanceAmount
 	// if minSwap == nil || minSwap.Cmp(big.NewInt(0)) == 0 {
 	// 	minSwap = big.NewInt(1e15) // 默认 0.001 token
 	// }
@@ -171,65 +243,262 @@ func (r *RebalanceService) checkAndRebalanceMarket() error {
 	// }
 
 	// 9. 执行 swap
-	return r.executeRebalanceMarket(directionAtoB, swapAmount)
+	return r.executeRebalanceMarket(ctx, directionAtoB, swapAmount)
+}
+
+// publishPoolState 发布本次检查读到的池状态，快照由订阅者按 SnapshotInterval 保存；
+// 手续费或区块高度读取失败只记日志，对应字段留空
+func (r *RebalanceService) publishPoolState(ctx context.Context, reserveA, reserveB *big.Int, marketPrice *big.Float, deviation float64) {
+	logger := logging.FromContext(ctx, r.logger)
+
+	market, _ := marketPrice.Float64()
+	state := events.PoolStateUpdated{
+		ReserveA:    reserveA.String(),
+		ReserveB:    reserveB.String(),
+		PoolPrice:   poolPrice(reserveA, reserveB),
+		MarketPrice: market,
+		Deviation:   deviation,
+	}
+	if fees, err := r.compoundService.contract.GetFees(&bind.CallOpts{Context: ctx}); err != nil {
+		logger.Warnf("读取手续费失败: %v", err)
+	} else {
+		state.FeeA, state.FeeB = fees.Arg0.String(), fees.Arg1.String()
+	}
+	if blockNumber, err := r.rpcClient.GetBlockNumber(ctx); err != nil {
+		logger.Warnf("读取区块高度失败: %v", err)
+	} else {
+		state.BlockNumber = blockNumber
+	}
+	publish(ctx, r.bus, r.pool, state)
+}
+
+// checkOracleStale 获取市场价格失败且上次成功获取已超过 OracleMaxAge 时发布 OracleStale
+func (r *RebalanceService) checkOracleStale(ctx context.Context, err error) {
+	if r.pool.OracleMaxAge <= 0 {
+		return
+	}
+	stale := events.OracleStale{MaxAgeSeconds: r.pool.OracleMaxAge.Seconds(), Error: err.Error()}
+	// 尚未获取过价格时，从服务启动时刻开始计算
+	since := r.compoundService.PriceUpdatedAt()
+	if since.IsZero() {
+		since = r.StartedAt()
+	} else {
+		stale.LastUpdated = &since
+	}
+	if since.IsZero() || time.Since(since) <= r.pool.OracleMaxAge {
+		return
+	}
+	publish(ctx, r.bus, r.pool, stale)
 }
 
 // executeRebalanceMarket: 发送链上交易并保存记录（与之前类似）
 // directionAtoB: true 表示把 A 换成 B（A->B），false 表示 B->A
-func (r *RebalanceService) executeRebalanceMarket(directionAtoB bool, amount *big.Int) error {
-	log.Infof("执行再平衡: directionAtoB=%t, amount=%s", directionAtoB, amount.String())
+func (r *RebalanceService) executeRebalanceMarket(ctx context.Context, directionAtoB bool, amount *big.Int) error {
+	logger := logging.FromContext(ctx, r.logger)
+	if r.IsReadOnly() {
+		logger.Warnf("只读模式，跳过再平衡交易: directionAtoB=%t, amount=%s", directionAtoB, amount.String())
+		publishSkipped(ctx, r.bus, r.pool, models.ActionTypeRebalance, events.SkipReadOnly, "")
+		return nil
+	}
+
+	// 发送前以签名账户身份模拟执行，避免把必然 revert 的交易发上链
+	simCtx, simSpan := startPoolSpan(ctx, "tx.simulate", r.pool,
+		attribute.String("tx.method", "rebalance"),
+		attribute.String("rebalance.amount_in", amount.String()),
+		attribute.Bool("rebalance.a_to_b", directionAtoB),
+	)
+	expectedOut, err := r.compoundService.contract.SimulateRebalance(&bind.CallOpts{Context: simCtx, From: r.txService.GetFromAddress()}, amount, directionAtoB)
+	if err == nil {
+		simSpan.SetAttributes(attribute.String("rebalance.amount_out", expectedOut.String()))
+	}
+	tracing.End(simSpan, err)
+	if err != nil {
+		return fmt.Errorf("模拟再平衡交易失败: %w", err)
+	}
+
+	logger.Infof("执行再平衡: directionAtoB=%t, amount=%s, 预计输出=%s", directionAtoB, amount.String(), expectedOut.String())
 
 	// 根据你的 txService 实现细节传参（这里保持和原来 ExecuteRebalance 类似的签名）
-	tx, err := r.txService.ExecuteRebalance(amount, directionAtoB)
+	tx, err := r.txService.ExecuteRebalance(ctx, r.compoundService.contract, amount, directionAtoB)
 	if err != nil {
 		return fmt.Errorf("执行再平衡交易失败: %w", err)
 	}
 
-	log.Infof("再平衡交易已发送: %s", tx.Hash().Hex())
+	sentAt := time.Now()
+	ctx = logging.WithFields(ctx, log.Fields{"tx_hash": tx.Hash().Hex(), "nonce": tx.Nonce()})
+	logger = logging.FromContext(ctx, r.logger)
+	logger.Info("再平衡交易已发送")
+	publishTxSent(ctx, r.bus, r.pool, models.ActionTypeRebalance, tx)
 
-	receipt, err := r.txService.WaitForReceipt(tx.Hash())
+	confirmation, err := r.txService.WaitForReceipt(ctx, tx)
 	if err != nil {
+		publishTxFailed(ctx, r.bus, r.pool, models.ActionTypeRebalance, tx, err)
 		return fmt.Errorf("等待交易确认失败: %w", err)
 	}
+	receipt := confirmation.Receipt
 
 	status := "failed"
+	var outcome *RebalanceOutcome
 	if receipt.Status == 1 {
-		log.Infof("✅ 再平衡成功! Gas 使用: %d", receipt.GasUsed)
+		logger.Infof("✅ 再平衡成功! Gas 使用: %d", receipt.GasUsed)
 		status = "success"
+
+		outcome, err = r.rebalanceOutcome(ctx, receipt)
+		if err != nil {
+			logger.Warnf("解析再平衡结果失败: %v", err)
+		} else {
+			logger.Infof("实际输入 %s %s, 输出 %s %s, 成交价格 %.8f",
+				outcome.AmountIn, outcome.TokenIn, outcome.AmountOut, outcome.TokenOut, outcome.EffectivePrice)
+			if outcome.ReserveABefore != nil {
+				logger.Infof("储备 %s/%s -> %s/%s", outcome.ReserveABefore, outcome.ReserveBBefore, outcome.ReserveAAfter, outcome.ReserveBAfter)
+			}
+			if outcome.ReservesApproximate {
+				logger.Warn("同一区块内还有其他交易改动了池储备，记录的交易前后储备之差不完全来自本次再平衡")
+			}
+		}
 	} else {
-		log.Error("❌ 再平衡交易失败")
+		logger.Error("❌ 再平衡交易失败")
 	}
 
-	// 保存记录
-	if r.repo != nil {
-		direction := "BtoA"
-		if directionAtoB {
-			direction = "AtoB"
-		}
-		action := &models.BotAction{
-			Timestamp:  time.Now(),
-			ActionType: models.ActionTypeRebalance,
-			AmountA:    amount.String(),
-			AmountB:    "0",
-			TxHash:     tx.Hash().Hex(),
-			Direction:  &direction,
-			Status:     status,
-			GasUsed:    receipt.GasUsed,
+	// 操作记录随 TxConfirmed 事件发布，由订阅者保存
+	direction := "BtoA"
+	if directionAtoB {
+		direction = "AtoB"
+	}
+	tokenIn, tokenOut := rebalanceTokens(directionAtoB)
+	action := &models.BotAction{
+		Pool:       r.pool.Name,
+		ChainID:    r.txService.Chain().ChainID,
+		Timestamp:  time.Now(),
+		ActionType: models.ActionTypeRebalance,
+		TxHash:     tx.Hash().Hex(),
+		Direction:  &direction,
+		Status:     status,
+		GasUsed:    receipt.GasUsed,
+		GasCostWei: receiptGasCost(receipt, tx).String(),
+		AmountIn:   amount.String(),
+		TokenIn:    tokenIn,
+		TokenOut:   tokenOut,
+	}
+	if receipt.BlockNumber != nil {
+		action.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	amountOut := big.NewInt(0)
+	if outcome != nil {
+		amountOut = outcome.AmountOut
+		action.AmountIn = outcome.AmountIn.String()
+		action.AmountOut = outcome.AmountOut.String()
+		action.EffectivePrice = outcome.EffectivePrice
+		if outcome.ReserveABefore != nil {
+			action.ReserveABefore = outcome.ReserveABefore.String()
+			action.ReserveBBefore = outcome.ReserveBBefore.String()
+			action.ReservesApproximate = outcome.ReservesApproximate
 		}
-		if err := r.repo.Create(action); err != nil {
-			log.Errorf("保存再平衡记录到数据库失败: %v", err)
-		} else {
-			log.Infof("✅ 再平衡记录已保存到数据库 (ID: %d)", action.ID)
+		if outcome.ReserveAAfter != nil {
+			action.ReserveAAfter = outcome.ReserveAAfter.String()
+			action.ReserveBAfter = outcome.ReserveBAfter.String()
 		}
 	}
+	// amountA/amountB 为该笔交易中 tokenA/tokenB 的数量，而不是固定把输入写在 amountA
+	if directionAtoB {
+		action.AmountA, action.AmountB = action.AmountIn, amountOut.String()
+	} else {
+		action.AmountA, action.AmountB = amountOut.String(), action.AmountIn
+	}
+	publishTxConfirmed(ctx, r.bus, r.pool, tx, confirmation, sentAt, action)
 
 	return nil
 }
 
+// RebalanceOutcome 从 Rebalance 事件和交易所在区块前后的储备得到的实际再平衡结果
+type RebalanceOutcome struct {
+	AmountIn       *big.Int
+	AmountOut      *big.Int
+	TokenIn        string // "A" 或 "B"
+	TokenOut       string
+	EffectivePrice float64 // 每个 tokenA 换得的 tokenB，与市场价格同一口径
+	// 交易所在区块前一个区块末尾与该区块末尾的储备，读取失败时为 nil；
+	// ReservesApproximate 表示同一区块内还有其他交易改动了储备，前后储备之差不完全来自本次交易
+	ReserveABefore      *big.Int
+	ReserveBBefore      *big.Int
+	ReserveAAfter       *big.Int
+	ReserveBAfter       *big.Int
+	ReservesApproximate bool
+}
+
+// rebalanceOutcome 解码 Rebalance 事件，并读取区块 BlockNumber-1 和 BlockNumber 的储备作为交易前后储备；
+// 节点不再保留这些区块的状态时储备留空，不按事件数量推算
+func (r *RebalanceService) rebalanceOutcome(ctx context.Context, receipt *types.Receipt) (*RebalanceOutcome, error) {
+	event, err := r.compoundService.contract.ParseRebalance(receipt)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, errors.New("交易回执中没有 Rebalance 事件")
+	}
+	if event.AmountIn.Sign() == 0 || event.AmountOut.Sign() == 0 {
+		return nil, fmt.Errorf("Rebalance 事件数量为 0: in=%s out=%s", event.AmountIn, event.AmountOut)
+	}
+
+	outcome := &RebalanceOutcome{
+		AmountIn:  event.AmountIn,
+		AmountOut: event.AmountOut,
+	}
+	outcome.TokenIn, outcome.TokenOut = rebalanceTokens(event.AtoB)
+	var price *big.Float
+	if event.AtoB {
+		price = new(big.Float).Quo(bigFloatFromInt(event.AmountOut), bigFloatFromInt(event.AmountIn))
+	} else {
+		price = new(big.Float).Quo(bigFloatFromInt(event.AmountIn), bigFloatFromInt(event.AmountOut))
+	}
+	outcome.EffectivePrice, _ = price.Float64()
+
+	logger := logging.FromContext(ctx, r.logger)
+	after, err := r.compoundService.contract.GetReserves(&bind.CallOpts{Context: ctx, BlockNumber: receipt.BlockNumber})
+	if err != nil {
+		logger.Warnf("读取交易后储备失败，记录中不保存储备: %v", err)
+		return outcome, nil
+	}
+	outcome.ReserveAAfter, outcome.ReserveBAfter = after.Arg0, after.Arg1
+
+	if receipt.BlockNumber.Sign() == 0 {
+		return outcome, nil
+	}
+	previous := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	before, err := r.compoundService.contract.GetReserves(&bind.CallOpts{Context: ctx, BlockNumber: previous})
+	if err != nil {
+		logger.Warnf("读取区块 %s 的储备失败，记录中不保存交易前储备: %v", previous, err)
+		return outcome, nil
+	}
+	outcome.ReserveABefore, outcome.ReserveBBefore = before.Arg0, before.Arg1
+
+	// 合约按 amountIn/amountOut 原样更新储备，不一致说明同一区块内有其他交易
+	expectedA, expectedB := new(big.Int).Add(before.Arg0, event.AmountIn), new(big.Int).Sub(before.Arg1, event.AmountOut)
+	if !event.AtoB {
+		expectedA, expectedB = new(big.Int).Sub(before.Arg0, event.AmountOut), new(big.Int).Add(before.Arg1, event.AmountIn)
+	}
+	outcome.ReservesApproximate = expectedA.Cmp(after.Arg0) != 0 || expectedB.Cmp(after.Arg1) != 0
+	return outcome, nil
+}
+
 //
 // ---------- 辅助函数 ----------
 //
 
+// rebalanceTokens 再平衡方向对应的输入、输出代币
+func rebalanceTokens(directionAtoB bool) (string, string) {
+	if directionAtoB {
+		return "A", "B"
+	}
+	return "B", "A"
+}
+
+// poolPrice 池内价格：每个 tokenA 对应的 tokenB（reserveB / reserveA）
+func poolPrice(reserveA, reserveB *big.Int) float64 {
+	price, _ := new(big.Float).Quo(bigFloatFromInt(reserveB), bigFloatFromInt(reserveA)).Float64()
+	return price
+}
+
 // bigFloatFromInt: 把 big.Int 转为 big.Float
 func bigFloatFromInt(i *big.Int) *big.Float {
 	return new(big.Float).SetInt(i)
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"mini-amm-bot/internal/metrics"
+)
+
+// 订阅断开后的重连退避上限
+const maxResubscribeBackoff = time.Minute
+
+// startEventDriven 事件驱动的再平衡：订阅 Swap 事件，大额 swap 后经过 RebalanceDebounce 合并再检查，
+// 两次检查至少间隔 RebalanceMinInterval；订阅断开期间回退到按调度规则检查
+func (r *RebalanceService) startEventDriven(ctx context.Context) {
+	fires := r.job.Start(ctx)
+
+	swaps := make(chan *SwapEvent, 64)
+	go r.watchSwaps(ctx, swaps)
+
+	r.markStarted()
+	r.logger.Infof("自动再平衡服务已启动 (事件驱动, 大额 swap 阈值 %.2f%%)", r.pool.LargeSwapFraction*100)
+
+	var debounce *time.Timer
+	var debounceC <-chan time.Time
+	var lastRun time.Time
+	run := func(trigger string) {
+		r.tick(ctx, trigger)
+		lastRun = time.Now()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if debounce != nil {
+				debounce.Stop()
+			}
+			r.logger.Info("自动再平衡服务已停止")
+			return
+		case swap := <-swaps:
+			large := r.isLargeSwap(swap)
+			metrics.SwapEvents.WithLabelValues(r.pool.Chain, r.pool.Name, ternary(large, "large", "small")).Inc()
+			if !large {
+				r.logger.Debugf("收到 Swap 事件 (区块 %d, amountIn=%s)，未达到大额阈值", swap.BlockNumber, swap.AmountIn.String())
+				continue
+			}
+			r.logger.Infof("收到大额 Swap 事件 (区块 %d, tx %s, amountIn=%s, AtoB=%t)",
+				swap.BlockNumber, swap.TxHash.Hex(), swap.AmountIn.String(), swap.AtoB)
+			if debounce == nil {
+				wait := r.pool.RebalanceDebounce
+				if remaining := r.pool.RebalanceMinInterval - time.Since(lastRun); remaining > wait {
+					wait = remaining
+				}
+				debounce = time.NewTimer(wait)
+				debounceC = debounce.C
+			}
+		case <-debounceC:
+			debounce, debounceC = nil, nil
+			run("swap")
+		case <-fires:
+			// 订阅正常且使用池内价格时，价格只随 swap 变化，由事件触发即可；
+			// 使用外部（模拟）价格时价格会独立变化，仍需定时检查
+			if r.IsSubscribed() && r.pool.SimulatedMarketPrice <= 0 {
+				continue
+			}
+			if time.Since(lastRun) < r.pool.RebalanceMinInterval {
+				continue
+			}
+			run("schedule")
+		}
+	}
+}
+
+// IsSubscribed Swap 事件订阅当前是否正常
+func (r *RebalanceService) IsSubscribed() bool {
+	return r.subscribed.Load()
+}
+
+func (r *RebalanceService) setSubscribed(subscribed bool) {
+	r.subscribed.Store(subscribed)
+	metrics.EventSubscriptionUp.WithLabelValues(r.pool.Chain, r.pool.Name).Set(metrics.BoolToFloat(subscribed))
+}
+
+// isLargeSwap amountIn 占输入侧储备（取最近一次检查时的储备）的比例是否达到 LargeSwapFraction，
+// 尚未读取过储备时一律视为大额
+func (r *RebalanceService) isLargeSwap(swap *SwapEvent) bool {
+	reserves := r.reserves.Load()
+	if reserves == nil || r.pool.LargeSwapFraction <= 0 {
+		return true
+	}
+	reserveIn := reserves[0]
+	if !swap.AtoB {
+		reserveIn = reserves[1]
+	}
+	if reserveIn.Sign() == 0 {
+		return true
+	}
+	ratio, _ := new(big.Float).Quo(bigFloatFromInt(swap.AmountIn), bigFloatFromInt(reserveIn)).Float64()
+	return ratio >= r.pool.LargeSwapFraction
+}
+
+// watchSwaps 保持 Swap 事件订阅，断开后按指数退避重连，直到 ctx 取消
+func (r *RebalanceService) watchSwaps(ctx context.Context, out chan<- *SwapEvent) {
+	backoff := time.Second
+	for {
+		established, err := r.subscribeSwaps(ctx, out)
+		r.setSubscribed(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if established {
+			backoff = time.Second
+		}
+		r.logger.Warnf("Swap 事件订阅断开，%s 后重连，期间回退到定时检查: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxResubscribeBackoff {
+			backoff = maxResubscribeBackoff
+		}
+	}
+}
+
+// subscribeSwaps 建立一次订阅并转发事件，返回订阅是否曾建立成功以及断开原因
+func (r *RebalanceService) subscribeSwaps(ctx context.Context, out chan<- *SwapEvent) (bool, error) {
+	chain := r.rpcClient.Chain()
+	contract := r.compoundService.Contract()
+
+	client, err := ethclient.DialContext(ctx, chain.WSEndpoint)
+	if err != nil {
+		return false, fmt.Errorf("连接 WebSocket 节点失败: %w", err)
+	}
+	defer client.Close()
+
+	logs := make(chan types.Log, 64)
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{contract.Address()},
+		Topics:    [][]common.Hash{{contract.SwapTopic()}},
+	}
+	sub, err := client.SubscribeFilterLogs(ctx, query, logs)
+	if err != nil {
+		return false, fmt.Errorf("订阅 Swap 事件失败: %w", err)
+	}
+	defer sub.Unsubscribe()
+
+	r.setSubscribed(true)
+	r.logger.Info("已订阅 Swap 事件")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return true, ctx.Err()
+		case err := <-sub.Err():
+			if err == nil {
+				err = errors.New("订阅已关闭")
+			}
+			return true, err
+		case vLog := <-logs:
+			if vLog.Removed {
+				// 链重组移除的日志，对应的储备变化已被回滚
+				continue
+			}
+			swap, err := contract.ParseSwap(vLog)
+			if err != nil {
+				r.logger.Warnf("解析 Swap 事件失败: %v", err)
+				continue
+			}
+			select {
+			case out <- swap:
+			case <-ctx.Done():
+				return true, ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"mini-amm-bot/internal/scheduler"
+	util "mini-amm-bot/internal/util"
+)
+
+// addJob 在共享调度器中注册池的复投/再平衡任务，按区块调度时通过该链的 RPC 客户端获取最新区块
+func addJob(sched *scheduler.Scheduler, pool *util.PoolConfig, action, schedule string, interval time.Duration, rpcClient *util.RPCClient) (*scheduler.Job, error) {
+	spec, err := scheduler.Parse(schedule, interval)
+	if err != nil {
+		return nil, fmt.Errorf("池 %s 的%s调度规则无效: %w", pool.Name, actionLabel(action), err)
+	}
+	return sched.Add(action+":"+pool.Name, spec, pool.ScheduleJitter, rpcClient.GetBlockNumber)
+}
+
+func actionLabel(action string) string {
+	if action == "compound" {
+		return "复投"
+	}
+	return "再平衡"
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"mini-amm-bot/internal/tracing"
+	util "mini-amm-bot/internal/util"
+)
+
+// startPoolSpan 创建带池和链属性的 span
+func startPoolSpan(ctx context.Context, name string, pool *util.PoolConfig, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("pool", pool.Name), attribute.String("chain", pool.Chain))
+	return tracing.Start(ctx, name, attrs...)
+}
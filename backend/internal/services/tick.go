@@ -0,0 +1,48 @@
+package services
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// tickTracker 记录服务最近一次执行和最近一次成功执行的时间，供就绪检查使用
+type tickTracker struct {
+	startedAt     atomic.Int64
+	lastTickAt    atomic.Int64
+	lastSuccessAt atomic.Int64
+}
+
+func (t *tickTracker) markStarted() {
+	t.startedAt.Store(time.Now().UnixNano())
+}
+
+// markTick 在每次 tick 结束时调用，err 为空视为成功
+func (t *tickTracker) markTick(err error) {
+	now := time.Now().UnixNano()
+	t.lastTickAt.Store(now)
+	if err == nil {
+		t.lastSuccessAt.Store(now)
+	}
+}
+
+// StartedAt 服务启动时间，未启动时为零值
+func (t *tickTracker) StartedAt() time.Time {
+	return unixNanoToTime(t.startedAt.Load())
+}
+
+// LastTickAt 最近一次执行时间，从未执行时为零值
+func (t *tickTracker) LastTickAt() time.Time {
+	return unixNanoToTime(t.lastTickAt.Load())
+}
+
+// LastSuccessAt 最近一次成功执行时间，从未成功时为零值
+func (t *tickTracker) LastSuccessAt() time.Time {
+	return unixNanoToTime(t.lastSuccessAt.Load())
+}
+
+func unixNanoToTime(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
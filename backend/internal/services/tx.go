@@ -3,33 +3,46 @@ package services
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/tracing"
 	util "mini-amm-bot/internal/util"
 )
 
 type TransactionService struct {
 	config      *util.Config
+	chain       *util.ChainConfig
 	rpcClient   *util.RPCClient
 	privateKey  *ecdsa.PrivateKey
 	fromAddress common.Address
-	contract    *MiniAMMContract
 	mutex       sync.Mutex // 互斥锁，防止并发交易
+	logger      *log.Entry
+
+	// nextNonce 本地维护的 nonce 序列，所有池共享同一签名账户时避免并发交易复用 nonce
+	nextNonce *uint64
 }
 
+// NewTransactionService 为一条链创建交易服务，签名账户与 gas 策略取自该链的配置
 func NewTransactionService(config *util.Config, rpcClient *util.RPCClient) (*TransactionService, error) {
+	chain := rpcClient.Chain()
+
 	// 处理私钥：去掉空白与可能的 0x 前缀，减少 HexToECDSA 因格式问题失败的概率
-	pkStr := strings.TrimSpace(config.PrivateKey)
+	pkStr := strings.TrimSpace(chain.PrivateKey)
 	pkStr = strings.TrimPrefix(pkStr, "0x")
 	if len(pkStr) != 64 {
 		return nil, fmt.Errorf("私钥长度不正确，期望 64 个十六进制字符，实际长度 %d", len(pkStr))
@@ -48,103 +61,275 @@ func NewTransactionService(config *util.Config, rpcClient *util.RPCClient) (*Tra
 
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	contract, err := NewMiniAMMContract(common.HexToAddress(config.ContractAddress), rpcClient.GetClient())
-	if err != nil {
-		return nil, err
-	}
-
 	return &TransactionService{
 		config:      config,
+		chain:       chain,
 		rpcClient:   rpcClient,
 		privateKey:  privateKey,
 		fromAddress: fromAddress,
-		contract:    contract,
+		logger:      logging.Component("tx").WithField("chain", chain.Name),
 	}, nil
 }
 
-func (t *TransactionService) GetTransactOpts() (*bind.TransactOpts, error) {
-	nonce, err := t.rpcClient.GetClient().PendingNonceAt(context.Background(), t.fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("获取 nonce 失败: %w", err)
-	}
-
-	gasPrice, err := t.rpcClient.GetClient().SuggestGasPrice(context.Background())
+// SuggestGasPrice 节点建议的 gas price，超过 MAX_GAS_PRICE 时取上限，与实际发送交易使用的价格一致
+func (t *TransactionService) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	start := time.Now()
+	conn := t.rpcClient.Conn()
+	gasPrice, err := conn.SuggestGasPrice(ctx)
+	t.rpcClient.Observe(ctx, conn, "eth_gasPrice", start, err)
 	if err != nil {
 		return nil, fmt.Errorf("获取 gas price 失败: %w", err)
 	}
 
-	maxGasPrice := big.NewInt(t.config.MaxGasPrice * 1e9)
+	maxGasPrice := big.NewInt(t.chain.MaxGasPrice * 1e9)
 	if gasPrice.Cmp(maxGasPrice) > 0 {
-		log.Warnf("Gas price 过高 (%s), 使用最大值 %s", gasPrice.String(), maxGasPrice.String())
+		logging.FromContext(ctx, t.logger).Warnf("Gas price 过高 (%s), 使用最大值 %s", gasPrice.String(), maxGasPrice.String())
 		gasPrice = maxGasPrice
 	}
+	return gasPrice, nil
+}
+
+// GetTransactOpts 构造交易参数，调用方需持有 mutex
+func (t *TransactionService) GetTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
+	nonce, err := t.reserveNonce(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取 nonce 失败: %w", err)
+	}
+
+	gasPrice, err := t.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	auth, err := bind.NewKeyedTransactorWithChainID(t.privateKey, big.NewInt(t.config.ChainID))
+	auth, err := bind.NewKeyedTransactorWithChainID(t.privateKey, big.NewInt(t.chain.ChainID))
 	if err != nil {
 		return nil, fmt.Errorf("创建交易签名器失败: %w", err)
 	}
 
+	auth.Context = ctx
 	auth.Nonce = big.NewInt(int64(nonce))
 	auth.Value = big.NewInt(0)
-	auth.GasLimit = t.config.GasLimit
+	auth.GasLimit = t.chain.GasLimit
 	auth.GasPrice = gasPrice
 
 	return auth, nil
 }
 
-func (t *TransactionService) ExecuteCompoundFees() (*types.Transaction, error) {
+func (t *TransactionService) ExecuteCompoundFees(ctx context.Context, contract *MiniAMMContract) (*types.Transaction, error) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	auth, err := t.GetTransactOpts()
+	auth, err := t.GetTransactOpts(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err := t.contract.CompoundFees(auth)
+	tx, err := contract.CompoundFees(auth)
 	if err != nil {
+		t.resetNonce()
 		return nil, fmt.Errorf("调用 compoundFees 失败: %w", err)
 	}
+	t.commitNonce(tx.Nonce())
 
 	return tx, nil
 }
 
-func (t *TransactionService) ExecuteRebalance(amount *big.Int, AtoB bool) (*types.Transaction, error) {
+func (t *TransactionService) ExecuteRebalance(ctx context.Context, contract *MiniAMMContract, amount *big.Int, AtoB bool) (*types.Transaction, error) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	auth, err := t.GetTransactOpts()
+	auth, err := t.GetTransactOpts(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err := t.contract.Rebalance(auth, amount, AtoB)
+	tx, err := contract.Rebalance(auth, amount, AtoB)
 	if err != nil {
+		t.resetNonce()
 		return nil, fmt.Errorf("调用 rebalance 失败: %w", err)
 	}
+	t.commitNonce(tx.Nonce())
 
 	return tx, nil
 }
 
-func (t *TransactionService) WaitForReceipt(txHash common.Hash) (*types.Receipt, error) {
+// reserveNonce 返回下一个可用 nonce：取节点 pending nonce 与本地序列中较大者，
+// 这样多个池的交易在前一笔尚未进入 pending 池时也不会冲突
+func (t *TransactionService) reserveNonce(ctx context.Context) (uint64, error) {
+	start := time.Now()
+	conn := t.rpcClient.Conn()
+	pending, err := conn.PendingNonceAt(ctx, t.fromAddress)
+	t.rpcClient.Observe(ctx, conn, "eth_getTransactionCount", start, err)
+	if err != nil {
+		return 0, err
+	}
+	if t.nextNonce != nil && *t.nextNonce > pending {
+		return *t.nextNonce, nil
+	}
+	return pending, nil
+}
+
+// commitNonce 交易广播成功后推进本地 nonce 序列
+func (t *TransactionService) commitNonce(used uint64) {
+	next := used + 1
+	t.nextNonce = &next
+}
+
+// resetNonce 广播失败时丢弃本地序列，下一笔交易重新以节点 pending nonce 为准
+func (t *TransactionService) resetNonce() {
+	t.nextNonce = nil
+}
+
+// ErrTxReplaced 交易的 nonce 已被另一笔交易使用（例如在钱包中加速或取消），该交易不会再上链
+var ErrTxReplaced = errors.New("交易已被同一 nonce 的其他交易替换")
+
+// Confirmation WaitForReceipt 的结果
+type Confirmation struct {
+	*types.Receipt
+	Confirmations    uint64 // 返回时的确认数
+	LowConfirmations bool   // CONFIRM_TIMEOUT 内未达到 CONFIRMATIONS，交易已上链但仍可能被重组
+}
+
+// WaitForReceipt 等待交易上链，并在链配置要求多个确认时在 ConfirmTimeout 内等待足够的区块深度；
+// 超时仍未达到要求的确认数时返回回执并标记 LowConfirmations，而不是报错。
+// 连续两次查不到回执且账户 nonce 已越过该交易时返回 ErrTxReplaced
+func (t *TransactionService) WaitForReceipt(ctx context.Context, tx *types.Transaction) (confirmation *Confirmation, err error) {
+	txHash := tx.Hash()
+	ctx, span := tracing.Start(ctx, "tx.wait_receipt",
+		attribute.String("tx.hash", txHash.Hex()),
+		attribute.Int64("tx.confirmations", int64(t.chain.Confirmations)),
+	)
+	defer func() {
+		if confirmation != nil {
+			span.SetAttributes(
+				attribute.Int64("tx.block_number", confirmation.BlockNumber.Int64()),
+				attribute.Int64("tx.gas_used", int64(confirmation.GasUsed)),
+				attribute.Int64("tx.status", int64(confirmation.Status)),
+				attribute.Bool("tx.low_confirmations", confirmation.LowConfirmations),
+			)
+		}
+		tracing.End(span, err)
+	}()
+
+	logger := logging.FromContext(ctx, t.logger).WithField("tx_hash", txHash.Hex())
+	receipt, err := t.waitMined(ctx, tx, logger)
+	if err != nil {
+		return nil, err
+	}
+	confirmation = &Confirmation{Receipt: receipt, Confirmations: 1}
+	if t.chain.Confirmations > 1 {
+		if err := t.waitConfirmations(ctx, confirmation, logger); err != nil {
+			return nil, err
+		}
+	}
+	t.refreshBalance()
+	return confirmation, nil
+}
+
+// waitMined 按 RetryAttempts × RetryDelay 轮询交易回执
+func (t *TransactionService) waitMined(ctx context.Context, tx *types.Transaction, logger *log.Entry) (*types.Receipt, error) {
+	nonceConsumed := 0
 	for i := 0; i < t.config.RetryAttempts; i++ {
-		time.Sleep(t.config.RetryDelay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(t.config.RetryDelay):
+		}
 
-		receipt, err := t.rpcClient.GetClient().TransactionReceipt(context.Background(), txHash)
+		start := time.Now()
+		conn := t.rpcClient.Conn()
+		receipt, err := conn.TransactionReceipt(ctx, tx.Hash())
+		if err != ethereum.NotFound {
+			// 交易尚未上链属于正常等待，不计为 RPC 错误
+			t.rpcClient.Observe(ctx, conn, "eth_getTransactionReceipt", start, err)
+		}
 		if err == nil {
 			return receipt, nil
 		}
+		// 回执可能只是尚未同步到当前节点，连续两次确认 nonce 已被使用才认为交易被替换
+		if t.nonceConsumed(ctx, tx.Nonce()) {
+			nonceConsumed++
+			if nonceConsumed >= 2 {
+				return nil, ErrTxReplaced
+			}
+		}
+		logger.Debugf("等待交易确认... (尝试 %d/%d)", i+1, t.config.RetryAttempts)
+	}
+	return nil, fmt.Errorf("交易确认超时")
+}
+
+// waitConfirmations 每 RetryDelay 检查一次区块高度，直到确认数达到 Confirmations 或 ConfirmTimeout 用完；
+// 超时只记警告并设置 LowConfirmations，只有 ctx 取消时返回错误
+func (t *TransactionService) waitConfirmations(ctx context.Context, confirmation *Confirmation, logger *log.Entry) error {
+	minedAt := confirmation.BlockNumber.Uint64()
+	deadline := time.Now().Add(t.chain.ConfirmTimeout)
+	for {
+		head, err := t.rpcClient.GetBlockNumber(ctx)
+		if err != nil {
+			logger.Debugf("获取区块高度失败: %v", err)
+		} else if head >= minedAt {
+			confirmation.Confirmations = head - minedAt + 1
+			if confirmation.Confirmations >= t.chain.Confirmations {
+				return nil
+			}
+			logger.Debugf("等待确认数... (区块 %d, 当前高度 %d, 需要 %d 个确认)", minedAt, head, t.chain.Confirmations)
+		}
 
-		log.Debugf("等待交易确认... (尝试 %d/%d)", i+1, t.config.RetryAttempts)
+		wait := min(t.config.RetryDelay, time.Until(deadline))
+		if wait <= 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
 	}
 
-	return nil, fmt.Errorf("交易确认超时")
+	confirmation.LowConfirmations = true
+	logger.Warnf("⚠️ 交易已在区块 %d 上链，但 %s 内只有 %d 个确认（需要 %d 个），按低确认数继续处理",
+		minedAt, t.chain.ConfirmTimeout, confirmation.Confirmations, t.chain.Confirmations)
+	return nil
+}
+
+// nonceConsumed 账户已上链的 nonce 是否越过了 nonce，查询失败时视为未越过
+func (t *TransactionService) nonceConsumed(ctx context.Context, nonce uint64) bool {
+	start := time.Now()
+	conn := t.rpcClient.Conn()
+	latest, err := conn.NonceAt(ctx, t.fromAddress, nil)
+	t.rpcClient.Observe(ctx, conn, "eth_getTransactionCount", start, err)
+	return err == nil && latest > nonce
 }
 
 func (t *TransactionService) GetBalance() (*big.Int, error) {
-	return t.rpcClient.GetClient().BalanceAt(context.Background(), t.fromAddress, nil)
+	return t.balanceAt(context.Background())
+}
+
+// refreshBalance 交易上链后刷新签名账户余额指标，失败只记日志
+func (t *TransactionService) refreshBalance() {
+	if _, err := t.GetBalance(); err != nil {
+		t.logger.Debugf("刷新签名账户余额失败: %v", err)
+	}
+}
+
+// balanceAt 查询签名账户余额并更新余额指标
+func (t *TransactionService) balanceAt(ctx context.Context) (*big.Int, error) {
+	start := time.Now()
+	conn := t.rpcClient.Conn()
+	balance, err := conn.BalanceAt(ctx, t.fromAddress, nil)
+	t.rpcClient.Observe(ctx, conn, "eth_getBalance", start, err)
+	if err != nil {
+		return nil, err
+	}
+	metrics.SignerBalance.WithLabelValues(t.chain.Name, t.fromAddress.Hex()).Set(metrics.WeiToUnit(balance, 18))
+	return balance, nil
 }
 
 func (t *TransactionService) GetFromAddress() common.Address {
 	return t.fromAddress
 }
+
+// Chain 返回该交易服务所属链的配置
+func (t *TransactionService) Chain() *util.ChainConfig {
+	return t.chain
+}
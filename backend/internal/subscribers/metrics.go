@@ -0,0 +1,37 @@
+package subscribers
+
+import (
+	"context"
+
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/metrics"
+)
+
+// Metrics 根据事件更新复投/再平衡的尝试次数、gas 和确认耗时指标
+func Metrics(ctx context.Context, event events.Event) {
+	switch data := event.Data.(type) {
+	case events.TickFinished:
+		if data.Error != "" {
+			metrics.ActionAttempts.WithLabelValues(event.Chain, event.Pool, string(data.Action), metrics.OutcomeError).Inc()
+		}
+	case events.ActionSkipped:
+		outcome := metrics.OutcomeSkipped
+		if data.Reason == events.SkipReadOnly {
+			outcome = metrics.OutcomeReadOnly
+		}
+		metrics.ActionAttempts.WithLabelValues(event.Chain, event.Pool, string(data.Action), outcome).Inc()
+	case events.TxConfirmed:
+		outcome := metrics.OutcomeSuccess
+		if data.Reverted {
+			outcome = metrics.OutcomeReverted
+		}
+		metrics.ActionAttempts.WithLabelValues(event.Chain, event.Pool, string(data.Action), outcome).Inc()
+
+		labels := []string{event.Chain, event.Pool, string(data.Action)}
+		metrics.GasUsed.WithLabelValues(labels...).Observe(float64(data.GasUsed))
+		if data.GasPriceGwei > 0 {
+			metrics.GasPrice.WithLabelValues(labels...).Observe(data.GasPriceGwei)
+		}
+		metrics.TxConfirmationSeconds.WithLabelValues(labels...).Observe(data.ConfirmSeconds)
+	}
+}
@@ -0,0 +1,111 @@
+// Package subscribers 事件总线的同步处理器：保存操作记录与池快照、更新 Prometheus 指标。
+// 服务只负责发布事件，各处理器互不依赖，可以单独替换或测试
+package subscribers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/models"
+	util "mini-amm-bot/internal/util"
+)
+
+// Recorder 把 TxConfirmed 中的操作记录写入数据库并发布 BotActionRecorded，
+// 按各池的 SnapshotInterval 把 PoolStateUpdated 保存为池快照
+type Recorder struct {
+	store     db.BotActionStore
+	snapshots db.PoolSnapshotStore
+	config    *util.Config
+	bus       *events.Bus
+	logger    *log.Entry
+
+	mu           sync.Mutex
+	lastSnapshot map[string]time.Time // 池名 -> 最近一次保存快照的时间
+}
+
+func NewRecorder(store db.BotActionStore, snapshots db.PoolSnapshotStore, config *util.Config, bus *events.Bus) *Recorder {
+	return &Recorder{
+		store:        store,
+		snapshots:    snapshots,
+		config:       config,
+		bus:          bus,
+		logger:       logging.Component("recorder"),
+		lastSnapshot: make(map[string]time.Time),
+	}
+}
+
+// Handle 注册到事件总线的处理器，保存失败只记日志
+func (r *Recorder) Handle(ctx context.Context, event events.Event) {
+	switch data := event.Data.(type) {
+	case events.TxConfirmed:
+		r.saveAction(ctx, event, data.Record)
+	case events.PoolStateUpdated:
+		r.saveSnapshot(ctx, event, data)
+	}
+}
+
+func (r *Recorder) saveAction(ctx context.Context, event events.Event, action *models.BotAction) {
+	if r.store == nil || action == nil {
+		return
+	}
+	logger := logging.FromContext(ctx, r.logger).WithFields(log.Fields{"pool": event.Pool, "chain": event.Chain})
+
+	if err := r.store.Create(ctx, action); err != nil {
+		logger.Errorf("保存%s记录到数据库失败: %v", actionName(action.ActionType), err)
+		return
+	}
+	logger.Infof("✅ %s记录已保存到数据库 (ID: %d)", actionName(action.ActionType), action.ID)
+	r.bus.Publish(ctx, events.Event{Chain: event.Chain, Pool: event.Pool, Data: events.BotActionRecorded{BotAction: action}})
+}
+
+// saveSnapshot 手续费或区块高度读取失败的状态不保存，等下一次检查
+func (r *Recorder) saveSnapshot(ctx context.Context, event events.Event, state events.PoolStateUpdated) {
+	pool := r.config.GetPool(event.Pool)
+	chain := r.config.GetChain(event.Chain)
+	if r.snapshots == nil || pool == nil || chain == nil || pool.SnapshotInterval <= 0 ||
+		state.BlockNumber == 0 || state.FeeA == "" {
+		return
+	}
+
+	r.mu.Lock()
+	if time.Since(r.lastSnapshot[pool.Name]) < pool.SnapshotInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastSnapshot[pool.Name] = event.Time
+	r.mu.Unlock()
+
+	snapshot := &models.PoolSnapshot{
+		Pool:        pool.Name,
+		ChainID:     chain.ChainID,
+		Timestamp:   event.Time,
+		BlockNumber: state.BlockNumber,
+		ReserveA:    state.ReserveA,
+		ReserveB:    state.ReserveB,
+		FeeA:        state.FeeA,
+		FeeB:        state.FeeB,
+		PoolPrice:   state.PoolPrice,
+		MarketPrice: state.MarketPrice,
+	}
+	if err := r.snapshots.Create(ctx, snapshot); err != nil {
+		logging.FromContext(ctx, r.logger).WithFields(log.Fields{"pool": event.Pool, "chain": event.Chain}).
+			Warnf("保存池快照失败: %v", err)
+		// 保存失败时下一次检查重试
+		r.mu.Lock()
+		delete(r.lastSnapshot, pool.Name)
+		r.mu.Unlock()
+	}
+}
+
+func actionName(actionType models.ActionType) string {
+	if actionType == models.ActionTypeRebalance {
+		return "再平衡"
+	}
+	return "复投"
+}
@@ -1,85 +1,643 @@
 package util
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
+
+	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/scheduler"
+)
+
+// DefaultPoolName 未配置 POOLS 时，单池模式使用的池名称
+const DefaultPoolName = "default"
+
+// DefaultChainName 未配置 CHAINS 时，单链模式使用的链名称
+const DefaultChainName = "default"
+
+// 再平衡触发方式
+const (
+	RebalanceModeInterval = "interval" // 按 REBALANCE_INTERVAL 定时检查
+	RebalanceModeEvent    = "event"    // 订阅 Swap 事件，大额 swap 后立即检查，订阅断开时回退到定时检查
+)
+
+// 启动自检失败时的处理方式
+const (
+	PreflightModeStrict   = "strict"   // 拒绝启动
+	PreflightModeReadOnly = "readonly" // 受影响的池降级为只读，不发送交易
 )
 
 type Config struct {
+	RetryAttempts  int
+	RetryDelay     time.Duration
+	DeploymentsDir string // Hardhat 部署记录目录，池配置了 NETWORK 时从这里读取合约地址
+	PreflightMode  string
+	LogFormat      string // text 或 json
+	LogLevel       string // 默认日志级别
+	LogLevels      string // 按组件覆盖的级别，如 "rpc=debug,tx=warn"
+	AdminToken     string // 管理接口的 Bearer token，为空时管理接口不可用
+	Database       db.Config
+	Alert          AlertConfig
+	Chains         []*ChainConfig
+	Pools          []*PoolConfig
+}
+
+// ChainConfig 单条链的连接、签名与 gas 策略配置，每条链拥有独立的 RPCClient 和 TransactionService
+type ChainConfig struct {
+	Name                 string
+	ChainID              int64
 	RPCEndpoint          string
 	FallbackRPCEndpoints []string
-	ContractAddress      string
+	WSEndpoint           string // WebSocket 节点，用于订阅合约事件；RPC_ENDPOINT 本身是 ws:// 时可不填
 	PrivateKey           string
-	ChainID              int64
+	GasLimit             uint64
+	MaxGasPrice          int64         // 单位 gwei
+	Confirmations        uint64        // 交易视为确认所需的区块数
+	ConfirmTimeout       time.Duration // 取得回执后等待确认数的最长时间，超时按低确认数处理而不是失败
+	MinBalance           *big.Int      // 签名账户最低 ETH 余额（wei），低于该值自检失败
+	MaxBlockAge          time.Duration // 最新区块时间与当前时间的最大差值，超过则未就绪；0 表示不检查
+	RPCHealthInterval    time.Duration // RPC 节点健康检查间隔
+	RPCFailoverThreshold int           // 当前节点连续失败多少次后切换
+	RPCMaxBlockLag       uint64        // 节点区块高度落后最高节点超过该值视为不可用
+	RPCQuorum            int           // 关键读取需要结果一致的节点数，小于 2 表示不启用
+	AlertMinBalance      *big.Int      // 签名账户余额低于该值（wei）时告警，默认与 MinBalance 相同；0 表示不告警
+}
+
+// PoolConfig 单个 MiniAMM 池的配置，每个池拥有独立的阈值、目标比例、价格源和执行间隔
+type PoolConfig struct {
+	Name                 string
+	Chain                string // 所属链名称，对应 ChainConfig.Name
+	ContractAddress      string
+	Network              string // Hardhat 网络名称，非空时合约地址取自部署记录
+	TokenAAddress        string // 来自部署记录，未使用部署记录时为空
+	TokenBAddress        string
+	DeploymentBlock      uint64 // MiniAMM 部署区块，未知时为 0
 	CompoundInterval     time.Duration
 	RebalanceInterval    time.Duration
 	RebalanceThreshold   float64
-	GasLimit             uint64
-	MaxGasPrice          int64
-	RetryAttempts        int
-	RetryDelay           time.Duration
-	TargetValueShare     float64  // 目标价值占比
-	MaxRebalanceFraction float64  // 单次最大再平衡比例
-	MinRebalanceAmount   *big.Int // 最小再平衡金额
-	SimulatedMarketPrice float64  // 模拟市场价格（A 相对于 B 的价格）
+	TargetValueShare     float64       // 目标价值占比
+	MaxRebalanceFraction float64       // 单次最大再平衡比例
+	MinRebalanceAmount   *big.Int      // 最小再平衡金额
+	SimulatedMarketPrice float64       // 模拟市场价格（A 相对于 B 的价格）
+	OracleMaxAge         time.Duration // 市场价格最长有效期，超过则未就绪
+
+	RebalanceMode        string        // interval 或 event
+	LargeSwapFraction    float64       // event 模式下 amountIn 占输入侧储备的比例达到该值才触发检查
+	RebalanceDebounce    time.Duration // event 模式下收到大额 swap 后等待合并后续 swap 的时间
+	RebalanceMinInterval time.Duration // event 模式下两次检查的最小间隔
+
+	CompoundSchedule  string        // 复投调度规则（cron / @every / @blocks），为空时按 CompoundInterval
+	RebalanceSchedule string        // 再平衡调度规则，为空时按 RebalanceInterval
+	ScheduleJitter    time.Duration // 每次触发额外随机延迟的上限
+
+	TokenBEthPrice         float64 // 1 个 tokenB 值多少 ETH，用于按 gas 成本判断复投是否划算；0 表示按固定阈值判断
+	CompoundProfitMultiple float64 // 手续费价值至少为预估 gas 成本的多少倍才复投
+
+	CompoundAutoInterval  bool          // 按推荐的最优间隔动态调整复投间隔
+	CompoundMinInterval   time.Duration // 推荐间隔下限
+	CompoundMaxInterval   time.Duration // 推荐间隔上限
+	CompoundHistoryWindow time.Duration // 估算手续费累积速度使用的历史窗口
+
+	SnapshotInterval time.Duration // 记录池状态快照的最小间隔，0 表示不记录
+
+	AlertFailedRebalances   int     // 连续多少次再平衡失败后告警，0 表示不告警
+	AlertDeviationThreshold float64 // 偏差超过该值持续 AlertDeviationDuration 后告警，0 表示不告警
+	AlertDeviationDuration  time.Duration
+}
+
+// AlertConfig 告警通知渠道与全局选项，未配置任何渠道时不发送告警
+type AlertConfig struct {
+	WebhookURL           string // 通用 webhook，POST JSON
+	SlackWebhookURL      string // Slack 兼容的 incoming webhook
+	SMTPHost             string
+	SMTPPort             int
+	SMTPUsername         string // 为空时不做 SMTP 认证
+	SMTPPassword         string
+	SMTPFrom             string
+	EmailTo              []string
+	Cooldown             time.Duration // 同一告警两次通知的最小间隔
+	BalanceCheckInterval time.Duration // 检查签名账户余额的间隔
+	OracleStale          bool          // 市场价格过期时告警
+	RPCFailover          bool          // RPC 节点切换时告警
+}
+
+var dotEnvOnce sync.Once
+
+// loadDotEnv 读取 .env 文件，已存在的环境变量优先
+func loadDotEnv() {
+	dotEnvOnce.Do(func() {
+		if err := godotenv.Load(); err != nil {
+			log.Warn("未找到 .env 文件，使用环境变量")
+		}
+	})
 }
 
 func LoadConfig() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
-		log.Warn("未找到 .env 文件，使用环境变量")
+	loadDotEnv()
+
+	database, err := LoadDatabaseConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	compoundInterval, _ := strconv.Atoi(getEnv("COMPOUND_INTERVAL", "300"))
-	rebalanceInterval, _ := strconv.Atoi(getEnv("REBALANCE_INTERVAL", "300"))
-	rebalanceThreshold, _ := strconv.ParseFloat(getEnv("REBALANCE_THRESHOLD", "0.10"), 64)
-	gasLimit, _ := strconv.ParseUint(getEnv("GAS_LIMIT", "300000"), 10, 64)
-	maxGasPrice, _ := strconv.ParseInt(getEnv("MAX_GAS_PRICE", "100"), 10, 64)
 	retryAttempts, _ := strconv.Atoi(getEnv("RETRY_ATTEMPTS", "3"))
 	retryDelay, _ := strconv.Atoi(getEnv("RETRY_DELAY", "5"))
-	chainID, _ := strconv.ParseInt(getEnv("CHAIN_ID", "31337"), 10, 64)
-	targetValueShare, _ := strconv.ParseFloat(getEnv("TARGET_VALUE_SHARE", "0.5"), 64)
-	maxRebalanceFraction, _ := strconv.ParseFloat(getEnv("MAX_REBALANCE_FRACTION", "0.005"), 64)
-	minRebalanceAmount := GetEnvBigInt("MIN_REBALANCE_AMOUNT", "1e15")
-	simulatedMarketPrice, _ := strconv.ParseFloat(getEnv("SIMULATED_MARKET_PRICE", "1"), 64)
+	deploymentsDir := getEnv("DEPLOYMENTS_DIR", "../contracts/deployments")
+	preflightMode := getEnv("PREFLIGHT_MODE", PreflightModeStrict)
+	if preflightMode != PreflightModeStrict && preflightMode != PreflightModeReadOnly {
+		return nil, fmt.Errorf("PREFLIGHT_MODE 无效: %s (可选 %s, %s)", preflightMode, PreflightModeStrict, PreflightModeReadOnly)
+	}
+
+	chains, err := loadChains()
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range chains {
+		if endpoints := 1 + len(chain.FallbackRPCEndpoints); chain.RPCQuorum > endpoints {
+			return nil, fmt.Errorf("链 %s 的 RPC_QUORUM=%d 超过 RPC 节点数 %d", chain.Name, chain.RPCQuorum, endpoints)
+		}
+	}
 
-	fallbackRPCs := []string{}
-	if fallback := getEnv("FALLBACK_RPC_ENDPOINTS", ""); fallback != "" {
-		fallbackRPCs = append(fallbackRPCs, fallback)
+	pools, err := loadPools(chains, deploymentsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		switch pool.RebalanceMode {
+		case RebalanceModeInterval:
+		case RebalanceModeEvent:
+			if chain := findChain(chains, pool.Chain); chain != nil && chain.WSEndpoint == "" {
+				return nil, fmt.Errorf("池 %s 使用 REBALANCE_MODE=event，但链 %s 未设置 WS_ENDPOINT", pool.Name, chain.Name)
+			}
+		default:
+			return nil, fmt.Errorf("池 %s 的 REBALANCE_MODE 无效: %s (可选 %s, %s)", pool.Name, pool.RebalanceMode, RebalanceModeInterval, RebalanceModeEvent)
+		}
+		compoundSpec, err := scheduler.Parse(pool.CompoundSchedule, pool.CompoundInterval)
+		if err != nil {
+			return nil, fmt.Errorf("池 %s 的 COMPOUND_SCHEDULE 无效: %w", pool.Name, err)
+		}
+		if pool.CompoundAutoInterval {
+			if compoundSpec.Kind != scheduler.KindInterval {
+				return nil, fmt.Errorf("池 %s 启用了 COMPOUND_AUTO_INTERVAL，COMPOUND_SCHEDULE 只能为空或 @every", pool.Name)
+			}
+			if pool.TokenBEthPrice <= 0 {
+				return nil, fmt.Errorf("池 %s 启用了 COMPOUND_AUTO_INTERVAL，需要设置 TOKEN_B_ETH_PRICE", pool.Name)
+			}
+		}
+		if pool.CompoundMinInterval <= 0 || pool.CompoundMaxInterval < pool.CompoundMinInterval {
+			return nil, fmt.Errorf("池 %s 的 COMPOUND_MIN_INTERVAL/COMPOUND_MAX_INTERVAL 无效", pool.Name)
+		}
+		if _, err := scheduler.Parse(pool.RebalanceSchedule, pool.RebalanceInterval); err != nil {
+			return nil, fmt.Errorf("池 %s 的 REBALANCE_SCHEDULE 无效: %w", pool.Name, err)
+		}
+	}
+
+	alert, err := loadAlertConfig()
+	if err != nil {
+		return nil, err
 	}
 
 	config := &Config{
-		RPCEndpoint:          getEnv("RPC_ENDPOINT", "http://localhost:8545"),
-		FallbackRPCEndpoints: fallbackRPCs,
-		ContractAddress:      getEnv("CONTRACT_ADDRESS", ""),
-		PrivateKey:           getEnv("PRIVATE_KEY", ""),
+		RetryAttempts:  retryAttempts,
+		RetryDelay:     time.Duration(retryDelay) * time.Second,
+		DeploymentsDir: deploymentsDir,
+		PreflightMode:  preflightMode,
+		LogFormat:      strings.ToLower(getEnv("LOG_FORMAT", "text")),
+		LogLevel:       getEnv("LOG_LEVEL", "info"),
+		LogLevels:      os.Getenv("LOG_LEVELS"),
+		AdminToken:     os.Getenv("ADMIN_TOKEN"),
+		Database:       database,
+		Alert:          alert,
+		Chains:         chains,
+		Pools:          pools,
+	}
+
+	return config, nil
+}
+
+// loadAlertConfig 读取告警渠道配置，阈值类的规则按链、按池配置
+func loadAlertConfig() (AlertConfig, error) {
+	smtpPort, _ := strconv.Atoi(getEnv("ALERT_SMTP_PORT", "587"))
+	cooldown, _ := strconv.Atoi(getEnv("ALERT_COOLDOWN", "1800"))
+	balanceCheckInterval, _ := strconv.Atoi(getEnv("ALERT_BALANCE_CHECK_INTERVAL", "300"))
+	oracleStale, _ := strconv.ParseBool(getEnv("ALERT_ORACLE_STALE", "true"))
+	rpcFailover, _ := strconv.ParseBool(getEnv("ALERT_RPC_FAILOVER", "true"))
+	if balanceCheckInterval <= 0 {
+		balanceCheckInterval = 300
+	}
+
+	config := AlertConfig{
+		WebhookURL:           os.Getenv("ALERT_WEBHOOK_URL"),
+		SlackWebhookURL:      os.Getenv("ALERT_SLACK_WEBHOOK_URL"),
+		SMTPHost:             os.Getenv("ALERT_SMTP_HOST"),
+		SMTPPort:             smtpPort,
+		SMTPUsername:         os.Getenv("ALERT_SMTP_USERNAME"),
+		SMTPPassword:         os.Getenv("ALERT_SMTP_PASSWORD"),
+		SMTPFrom:             os.Getenv("ALERT_SMTP_FROM"),
+		EmailTo:              splitList(os.Getenv("ALERT_EMAIL_TO")),
+		Cooldown:             time.Duration(cooldown) * time.Second,
+		BalanceCheckInterval: time.Duration(balanceCheckInterval) * time.Second,
+		OracleStale:          oracleStale,
+		RPCFailover:          rpcFailover,
+	}
+
+	if config.SMTPHost != "" && (config.SMTPFrom == "" || len(config.EmailTo) == 0) {
+		return config, errors.New("设置了 ALERT_SMTP_HOST，但未设置 ALERT_SMTP_FROM 或 ALERT_EMAIL_TO")
+	}
+	if config.SMTPPort <= 0 || config.SMTPPort > 65535 {
+		return config, fmt.Errorf("ALERT_SMTP_PORT 无效: %d", config.SMTPPort)
+	}
+	if config.Cooldown < 0 {
+		return config, errors.New("ALERT_COOLDOWN 不能为负数")
+	}
+	return config, nil
+}
+
+// LoadDatabaseConfig 读取存储后端配置；migrate 子命令只需要这部分配置，不读取链和私钥
+func LoadDatabaseConfig() (db.Config, error) {
+	loadDotEnv()
+
+	port, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
+	maxOpenConns, _ := strconv.Atoi(getEnv("DB_MAX_OPEN_CONNS", "25"))
+	maxIdleConns, _ := strconv.Atoi(getEnv("DB_MAX_IDLE_CONNS", "5"))
+	connMaxLifetime, _ := strconv.Atoi(getEnv("DB_CONN_MAX_LIFETIME", "300"))
+	connMaxIdleTime, _ := strconv.Atoi(getEnv("DB_CONN_MAX_IDLE_TIME", "0"))
+	connectTimeout, _ := strconv.Atoi(getEnv("DB_CONNECT_TIMEOUT", "60"))
+
+	config := db.Config{
+		Driver:          db.Driver(strings.ToLower(getEnv("DB_DRIVER", string(db.DriverPostgres)))),
+		DSN:             getEnv("DATABASE_URL", os.Getenv("DB_DSN")),
+		Host:            getEnv("DB_HOST", "postgres"),
+		Port:            port,
+		User:            getEnv("DB_USER", "graph-node"),
+		Password:        getEnv("DB_PASSWORD", "let-me-in"),
+		DBName:          getEnv("DB_NAME", "graph-node"),
+		SSLMode:         getEnv("DB_SSLMODE", "disable"),
+		SSLRootCert:     os.Getenv("DB_SSLROOTCERT"),
+		SSLCert:         os.Getenv("DB_SSLCERT"),
+		SSLKey:          os.Getenv("DB_SSLKEY"),
+		MaxOpenConns:    maxOpenConns,
+		MaxIdleConns:    maxIdleConns,
+		ConnMaxLifetime: time.Duration(connMaxLifetime) * time.Second,
+		ConnMaxIdleTime: time.Duration(connMaxIdleTime) * time.Second,
+		ConnectTimeout:  time.Duration(connectTimeout) * time.Second,
+		SQLitePath:      getEnv("SQLITE_PATH", "keeper.db"),
+	}
+
+	switch config.Driver {
+	case db.DriverPostgres, db.DriverSQLite, db.DriverMemory:
+	default:
+		return config, fmt.Errorf("DB_DRIVER 无效: %s (可选 %s, %s, %s)", config.Driver, db.DriverPostgres, db.DriverSQLite, db.DriverMemory)
+	}
+	if !slices.Contains(db.SSLModes, config.SSLMode) {
+		return config, fmt.Errorf("DB_SSLMODE 无效: %s (可选 %s)", config.SSLMode, strings.Join(db.SSLModes, ", "))
+	}
+	if config.Port <= 0 || config.Port > 65535 {
+		return config, fmt.Errorf("DB_PORT 无效: %d", config.Port)
+	}
+	if config.MaxOpenConns < 0 || config.MaxIdleConns < 0 || (config.MaxOpenConns > 0 && config.MaxIdleConns > config.MaxOpenConns) {
+		return config, fmt.Errorf("DB_MAX_OPEN_CONNS=%d / DB_MAX_IDLE_CONNS=%d 无效，空闲连接数不能超过最大连接数", config.MaxOpenConns, config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime < 0 || config.ConnMaxIdleTime < 0 || config.ConnectTimeout < 0 {
+		return config, errors.New("DB_CONN_MAX_LIFETIME / DB_CONN_MAX_IDLE_TIME / DB_CONNECT_TIMEOUT 不能为负数")
+	}
+	return config, nil
+}
+
+// GetChain 按名称查找链配置
+func (c *Config) GetChain(name string) *ChainConfig {
+	return findChain(c.Chains, name)
+}
+
+func findChain(chains []*ChainConfig, name string) *ChainConfig {
+	for _, chain := range chains {
+		if chain.Name == name {
+			return chain
+		}
+	}
+	return nil
+}
+
+// GetPool 按名称查找池配置
+func (c *Config) GetPool(name string) *PoolConfig {
+	for _, pool := range c.Pools {
+		if pool.Name == name {
+			return pool
+		}
+	}
+	return nil
+}
+
+// loadChains 解析链列表。
+// 未设置 CHAINS 时退化为单链模式，直接读取 RPC_ENDPOINT、CHAIN_ID、PRIVATE_KEY 等全局变量；
+// 设置 CHAINS=local,sepolia 时，每条链从 CHAIN_<NAME>_<KEY> 读取配置，
+// 除 ID 和 RPC_ENDPOINT 外的缺省值回退到同名全局变量。
+func loadChains() ([]*ChainConfig, error) {
+	names := splitList(getEnv("CHAINS", ""))
+	if len(names) == 0 {
+		chain, err := loadChain(DefaultChainName, "")
+		if err != nil {
+			return nil, err
+		}
+		if chain.PrivateKey == "" {
+			log.Fatal("PRIVATE_KEY 未设置")
+		}
+		return []*ChainConfig{chain}, nil
+	}
+
+	chains := make([]*ChainConfig, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	seenIDs := make(map[int64]string, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("链名称重复: %s", name)
+		}
+		seen[name] = true
+
+		prefix := "CHAIN_" + envKey(name) + "_"
+		chain, err := loadChain(name, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if chain.ChainID == 0 {
+			return nil, fmt.Errorf("链 %s 未设置 %sID", name, prefix)
+		}
+		if chain.RPCEndpoint == "" {
+			return nil, fmt.Errorf("链 %s 未设置 %sRPC_ENDPOINT", name, prefix)
+		}
+		if chain.PrivateKey == "" {
+			return nil, fmt.Errorf("链 %s 未设置 %sPRIVATE_KEY 或 PRIVATE_KEY", name, prefix)
+		}
+		if other, ok := seenIDs[chain.ChainID]; ok {
+			return nil, fmt.Errorf("链 %s 与 %s 的 Chain ID 重复: %d", name, other, chain.ChainID)
+		}
+		seenIDs[chain.ChainID] = name
+		chains = append(chains, chain)
+	}
+
+	return chains, nil
+}
+
+func loadChain(name, prefix string) (*ChainConfig, error) {
+	get := func(key, defaultValue string) string {
+		if prefix != "" {
+			if value := os.Getenv(prefix + key); value != "" {
+				return value
+			}
+		}
+		return getEnv(key, defaultValue)
+	}
+
+	gasLimit, _ := strconv.ParseUint(get("GAS_LIMIT", "300000"), 10, 64)
+	maxGasPrice, _ := strconv.ParseInt(get("MAX_GAS_PRICE", "100"), 10, 64)
+	confirmations, _ := strconv.ParseUint(get("CONFIRMATIONS", "1"), 10, 64)
+	maxBlockAge, _ := strconv.Atoi(get("MAX_BLOCK_AGE", "0"))
+	rpcHealthInterval, _ := strconv.Atoi(get("RPC_HEALTH_INTERVAL", "15"))
+	rpcFailoverThreshold, _ := strconv.Atoi(get("RPC_FAILOVER_THRESHOLD", "3"))
+	rpcMaxBlockLag, _ := strconv.ParseUint(get("RPC_MAX_BLOCK_LAG", "5"), 10, 64)
+	rpcQuorum, _ := strconv.Atoi(get("RPC_QUORUM", "0"))
+	if rpcHealthInterval <= 0 {
+		rpcHealthInterval = 15
+	}
+	if rpcFailoverThreshold <= 0 {
+		rpcFailoverThreshold = 1
+	}
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	// 默认按每个确认 15 秒估算等待时间
+	confirmTimeout, _ := strconv.Atoi(get("CONFIRM_TIMEOUT", "0"))
+	if confirmTimeout <= 0 {
+		confirmTimeout = int(confirmations) * 15
+	}
+
+	minBalance, err := ParseEther(get("MIN_ETH_BALANCE", "0.01"))
+	if err != nil {
+		return nil, fmt.Errorf("链 %s 的 MIN_ETH_BALANCE 无效: %w", name, err)
+	}
+	alertMinBalance, err := ParseEther(get("ALERT_MIN_ETH_BALANCE", get("MIN_ETH_BALANCE", "0.01")))
+	if err != nil {
+		return nil, fmt.Errorf("链 %s 的 ALERT_MIN_ETH_BALANCE 无效: %w", name, err)
+	}
+
+	// Chain ID 与 RPC 节点不回退到全局变量，避免多条链误连同一节点
+	chainID, _ := strconv.ParseInt(getEnv("CHAIN_ID", "31337"), 10, 64)
+	rpcEndpoint := getEnv("RPC_ENDPOINT", "http://localhost:8545")
+	fallback := getEnv("FALLBACK_RPC_ENDPOINTS", "")
+	wsEndpoint := getEnv("WS_ENDPOINT", "")
+	if prefix != "" {
+		chainID, _ = strconv.ParseInt(os.Getenv(prefix+"ID"), 10, 64)
+		rpcEndpoint = os.Getenv(prefix + "RPC_ENDPOINT")
+		fallback = os.Getenv(prefix + "FALLBACK_RPC_ENDPOINTS")
+		wsEndpoint = os.Getenv(prefix + "WS_ENDPOINT")
+	}
+	if wsEndpoint == "" && (strings.HasPrefix(rpcEndpoint, "ws://") || strings.HasPrefix(rpcEndpoint, "wss://")) {
+		wsEndpoint = rpcEndpoint
+	}
+
+	return &ChainConfig{
+		Name:                 name,
 		ChainID:              chainID,
-		CompoundInterval:     time.Duration(compoundInterval) * time.Second,
-		RebalanceInterval:    time.Duration(rebalanceInterval) * time.Second,
-		RebalanceThreshold:   rebalanceThreshold,
+		RPCEndpoint:          rpcEndpoint,
+		FallbackRPCEndpoints: splitList(fallback),
+		WSEndpoint:           wsEndpoint,
+		PrivateKey:           get("PRIVATE_KEY", ""),
 		GasLimit:             gasLimit,
 		MaxGasPrice:          maxGasPrice,
-		RetryAttempts:        retryAttempts,
-		RetryDelay:           time.Duration(retryDelay) * time.Second,
-		TargetValueShare:     targetValueShare,
-		MaxRebalanceFraction: maxRebalanceFraction,
-		MinRebalanceAmount:   minRebalanceAmount,
-		SimulatedMarketPrice: simulatedMarketPrice,
+		Confirmations:        confirmations,
+		ConfirmTimeout:       time.Duration(confirmTimeout) * time.Second,
+		MinBalance:           minBalance,
+		AlertMinBalance:      alertMinBalance,
+		MaxBlockAge:          time.Duration(maxBlockAge) * time.Second,
+		RPCHealthInterval:    time.Duration(rpcHealthInterval) * time.Second,
+		RPCFailoverThreshold: rpcFailoverThreshold,
+		RPCMaxBlockLag:       rpcMaxBlockLag,
+		RPCQuorum:            rpcQuorum,
+	}, nil
+}
+
+// loadPools 解析池列表。
+// 未设置 POOLS 时退化为单池模式，直接读取 CONTRACT_ADDRESS 等全局变量；
+// 设置 POOLS=main,alt 时，每个池从 POOL_<NAME>_<KEY> 读取配置，缺省值回退到同名全局变量。
+// 池通过 POOL_<NAME>_CHAIN 指定所属链，只配置了一条链时可省略。
+// 设置了 NETWORK（或 POOL_<NAME>_NETWORK）的池从 deploymentsDir 中的部署记录读取合约地址。
+func loadPools(chains []*ChainConfig, deploymentsDir string) ([]*PoolConfig, error) {
+	names := splitList(getEnv("POOLS", ""))
+	if len(names) == 0 {
+		pool := loadPool(DefaultPoolName, "")
+		if err := resolvePoolChain(pool, chains); err != nil {
+			return nil, err
+		}
+		if err := applyDeployment(pool, chains, deploymentsDir); err != nil {
+			return nil, err
+		}
+		if pool.ContractAddress == "" {
+			log.Fatal("CONTRACT_ADDRESS 或 NETWORK 未设置")
+		}
+		return []*PoolConfig{pool}, nil
 	}
 
-	if config.ContractAddress == "" {
-		log.Fatal("CONTRACT_ADDRESS 未设置")
+	pools := make([]*PoolConfig, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("池名称重复: %s", name)
+		}
+		seen[name] = true
+
+		prefix := "POOL_" + envKey(name) + "_"
+		pool := loadPool(name, prefix)
+		if err := resolvePoolChain(pool, chains); err != nil {
+			return nil, err
+		}
+		if err := applyDeployment(pool, chains, deploymentsDir); err != nil {
+			return nil, err
+		}
+		if pool.ContractAddress == "" {
+			return nil, fmt.Errorf("池 %s 未设置 %sCONTRACT_ADDRESS 或 %sNETWORK", name, prefix, prefix)
+		}
+		pools = append(pools, pool)
 	}
-	if config.PrivateKey == "" {
-		log.Fatal("PRIVATE_KEY 未设置")
+
+	return pools, nil
+}
+
+// applyDeployment 池配置了 Network 时读取部署记录，填充合约地址、代币地址和部署区块，
+// 并校验部署记录的 Chain ID 与池所属链一致。显式配置的 CONTRACT_ADDRESS 优先。
+func applyDeployment(pool *PoolConfig, chains []*ChainConfig, deploymentsDir string) error {
+	if pool.Network == "" {
+		return nil
 	}
 
-	return config, nil
+	deployment, err := LoadDeployment(deploymentsDir, pool.Network)
+	if err != nil {
+		return fmt.Errorf("池 %s: %w", pool.Name, err)
+	}
+
+	for _, chain := range chains {
+		if chain.Name == pool.Chain && deployment.ChainID != 0 && deployment.ChainID != chain.ChainID {
+			return fmt.Errorf("池 %s: 部署记录 Chain ID %d 与链 %s 的 Chain ID %d 不一致",
+				pool.Name, deployment.ChainID, chain.Name, chain.ChainID)
+		}
+	}
+
+	if pool.ContractAddress == "" {
+		pool.ContractAddress = deployment.Contracts.MiniAMM
+	} else if !strings.EqualFold(pool.ContractAddress, deployment.Contracts.MiniAMM) {
+		log.Warnf("池 %s 的 CONTRACT_ADDRESS (%s) 与 %s 部署记录 (%s) 不一致，使用显式配置",
+			pool.Name, pool.ContractAddress, pool.Network, deployment.Contracts.MiniAMM)
+	}
+	pool.TokenAAddress = deployment.Contracts.TokenA
+	pool.TokenBAddress = deployment.Contracts.TokenB
+	pool.DeploymentBlock = deployment.DeploymentBlock
+
+	return nil
+}
+
+// resolvePoolChain 校验池所属链，只有一条链时自动归属
+func resolvePoolChain(pool *PoolConfig, chains []*ChainConfig) error {
+	if pool.Chain == "" {
+		if len(chains) != 1 {
+			return fmt.Errorf("池 %s 未指定所属链 (POOL_<NAME>_CHAIN)", pool.Name)
+		}
+		pool.Chain = chains[0].Name
+		return nil
+	}
+
+	for _, chain := range chains {
+		if chain.Name == pool.Chain {
+			return nil
+		}
+	}
+	return fmt.Errorf("池 %s 引用了未配置的链: %s", pool.Name, pool.Chain)
+}
+
+func loadPool(name, prefix string) *PoolConfig {
+	get := func(key, defaultValue string) string {
+		if prefix != "" {
+			if value := os.Getenv(prefix + key); value != "" {
+				return value
+			}
+		}
+		return getEnv(key, defaultValue)
+	}
+
+	compoundInterval, _ := strconv.Atoi(get("COMPOUND_INTERVAL", "300"))
+	rebalanceInterval, _ := strconv.Atoi(get("REBALANCE_INTERVAL", "300"))
+	rebalanceThreshold, _ := strconv.ParseFloat(get("REBALANCE_THRESHOLD", "0.10"), 64)
+	targetValueShare, _ := strconv.ParseFloat(get("TARGET_VALUE_SHARE", "0.5"), 64)
+	maxRebalanceFraction, _ := strconv.ParseFloat(get("MAX_REBALANCE_FRACTION", "0.005"), 64)
+	minRebalanceAmount := ParseBigInt(get("MIN_REBALANCE_AMOUNT", "1e15"))
+	simulatedMarketPrice, _ := strconv.ParseFloat(get("SIMULATED_MARKET_PRICE", "1"), 64)
+	// 默认允许错过两次再平衡检查
+	oracleMaxAge, _ := strconv.Atoi(get("ORACLE_MAX_AGE", strconv.Itoa(3*rebalanceInterval)))
+	largeSwapFraction, _ := strconv.ParseFloat(get("LARGE_SWAP_FRACTION", "0.005"), 64)
+	rebalanceDebounce, _ := strconv.Atoi(get("REBALANCE_DEBOUNCE_MS", "2000"))
+	rebalanceMinInterval, _ := strconv.Atoi(get("REBALANCE_MIN_INTERVAL", "12"))
+	scheduleJitter, _ := strconv.Atoi(get("SCHEDULE_JITTER", "0"))
+	tokenBEthPrice, _ := strconv.ParseFloat(get("TOKEN_B_ETH_PRICE", "0"), 64)
+	compoundProfitMultiple, _ := strconv.ParseFloat(get("COMPOUND_PROFIT_MULTIPLE", "2"), 64)
+	compoundAutoInterval, _ := strconv.ParseBool(get("COMPOUND_AUTO_INTERVAL", "false"))
+	compoundMinInterval, _ := strconv.Atoi(get("COMPOUND_MIN_INTERVAL", "60"))
+	compoundMaxInterval, _ := strconv.Atoi(get("COMPOUND_MAX_INTERVAL", "86400"))
+	compoundHistoryWindow, _ := strconv.Atoi(get("COMPOUND_HISTORY_WINDOW", "604800"))
+	snapshotInterval, _ := strconv.Atoi(get("SNAPSHOT_INTERVAL", "300"))
+	alertFailedRebalances, _ := strconv.Atoi(get("ALERT_FAILED_REBALANCES", "3"))
+	alertDeviationThreshold, _ := strconv.ParseFloat(get("ALERT_DEVIATION_THRESHOLD", "0"), 64)
+	alertDeviationDuration, _ := strconv.Atoi(get("ALERT_DEVIATION_DURATION", "600"))
+
+	// 合约地址与部署网络不回退到全局变量，避免多个池误指向同一合约
+	contractAddress := getEnv("CONTRACT_ADDRESS", "")
+	network := getEnv("NETWORK", "")
+	chain := ""
+	if prefix != "" {
+		contractAddress = os.Getenv(prefix + "CONTRACT_ADDRESS")
+		network = os.Getenv(prefix + "NETWORK")
+		chain = os.Getenv(prefix + "CHAIN")
+	}
+
+	return &PoolConfig{
+		Name:                    name,
+		Chain:                   chain,
+		ContractAddress:         contractAddress,
+		Network:                 network,
+		CompoundInterval:        time.Duration(compoundInterval) * time.Second,
+		RebalanceInterval:       time.Duration(rebalanceInterval) * time.Second,
+		RebalanceThreshold:      rebalanceThreshold,
+		TargetValueShare:        targetValueShare,
+		MaxRebalanceFraction:    maxRebalanceFraction,
+		MinRebalanceAmount:      minRebalanceAmount,
+		SimulatedMarketPrice:    simulatedMarketPrice,
+		OracleMaxAge:            time.Duration(oracleMaxAge) * time.Second,
+		RebalanceMode:           strings.ToLower(get("REBALANCE_MODE", RebalanceModeInterval)),
+		LargeSwapFraction:       largeSwapFraction,
+		RebalanceDebounce:       time.Duration(rebalanceDebounce) * time.Millisecond,
+		RebalanceMinInterval:    time.Duration(rebalanceMinInterval) * time.Second,
+		CompoundSchedule:        get("COMPOUND_SCHEDULE", ""),
+		RebalanceSchedule:       get("REBALANCE_SCHEDULE", ""),
+		ScheduleJitter:          time.Duration(scheduleJitter) * time.Second,
+		TokenBEthPrice:          tokenBEthPrice,
+		CompoundProfitMultiple:  compoundProfitMultiple,
+		CompoundAutoInterval:    compoundAutoInterval,
+		CompoundMinInterval:     time.Duration(compoundMinInterval) * time.Second,
+		CompoundMaxInterval:     time.Duration(compoundMaxInterval) * time.Second,
+		CompoundHistoryWindow:   time.Duration(compoundHistoryWindow) * time.Second,
+		SnapshotInterval:        time.Duration(snapshotInterval) * time.Second,
+		AlertFailedRebalances:   alertFailedRebalances,
+		AlertDeviationThreshold: alertDeviationThreshold,
+		AlertDeviationDuration:  time.Duration(alertDeviationDuration) * time.Second,
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -89,12 +647,51 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// splitList 解析逗号分隔的列表，忽略空白项
+func splitList(raw string) []string {
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envKey 把名称转换为环境变量片段，例如 "eth-usdc" -> "ETH_USDC"
+func envKey(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(name))
+}
+
 // GetEnvBigInt: 从 env 读取并解析为 *big.Int，支持整数字符串或科学计数法如 "1e15"
 func GetEnvBigInt(key string, defaultVal string) *big.Int {
 	raw := os.Getenv(key)
 	if raw == "" {
 		raw = defaultVal
 	}
+	return ParseBigInt(raw)
+}
+
+// ParseEther: 把以 ETH 为单位的十进制字符串（如 "0.05"）精确转换为 wei，小数位最多 18 位
+func ParseEther(raw string) (*big.Int, error) {
+	value := strings.TrimSpace(raw)
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" && frac == "" || len(frac) > 18 || strings.HasPrefix(frac, "+") || strings.HasPrefix(frac, "-") {
+		return nil, fmt.Errorf("无效的 ETH 数量: %q", raw)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	// 整数部分与补齐到 18 位的小数部分拼接即为 wei
+	wei, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", 18-len(frac)), 10)
+	if !ok || wei.Sign() < 0 {
+		return nil, fmt.Errorf("无效的 ETH 数量: %q", raw)
+	}
+	return wei, nil
+}
+
+// ParseBigInt: 解析整数字符串或科学计数法（如 "1e15"）为 *big.Int，解析失败返回 0
+func ParseBigInt(raw string) *big.Int {
 	// 尝试直接解析为整数
 	i := new(big.Int)
 	if _, ok := i.SetString(raw, 10); ok {
@@ -0,0 +1,50 @@
+package util
+
+import "testing"
+
+func TestParseEther(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"0.01", "10000000000000000"},
+		{"0.1", "100000000000000000"},
+		{" 1.5 ", "1500000000000000000"},
+		{"2", "2000000000000000000"},
+		{"3.", "3000000000000000000"},
+		{".25", "250000000000000000"},
+		{"0.000000000000000001", "1"},
+		{"123456789.123456789123456789", "123456789123456789123456789"},
+		{"0", "0"},
+	}
+	for _, tt := range tests {
+		got, err := ParseEther(tt.raw)
+		if err != nil {
+			t.Errorf("ParseEther(%q): %v", tt.raw, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseEther(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseEtherInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		" ",
+		".",
+		"abc",
+		"0.05eth",
+		"1e-2",
+		"-0.5",
+		"1.2.3",
+		"1.-5",
+		"0x10",
+		"0.0000000000000000001",
+	} {
+		if got, err := ParseEther(raw); err == nil {
+			t.Errorf("ParseEther(%q) = %s, 应返回错误", raw, got)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Deployment Hardhat 部署脚本写入 contracts/deployments/<network>-latest.json 的部署记录
+type Deployment struct {
+	Network   string `json:"network"`
+	ChainID   int64  `json:"chainId"`
+	Deployer  string `json:"deployer"`
+	Contracts struct {
+		TokenA  string `json:"tokenA"`
+		TokenB  string `json:"tokenB"`
+		MiniAMM string `json:"miniAMM"`
+	} `json:"contracts"`
+	// DeploymentBlock MiniAMM 部署所在区块，旧版部署记录没有该字段时为 0
+	DeploymentBlock uint64 `json:"deploymentBlock"`
+	Timestamp       string `json:"timestamp"`
+}
+
+// LoadDeployment 读取指定网络最新的部署记录并校验地址格式
+func LoadDeployment(dir, network string) (*Deployment, error) {
+	path := filepath.Join(dir, network+"-latest.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取部署记录失败: %w", err)
+	}
+
+	var deployment Deployment
+	if err := json.Unmarshal(data, &deployment); err != nil {
+		return nil, fmt.Errorf("解析部署记录 %s 失败: %w", path, err)
+	}
+
+	if deployment.Network != "" && deployment.Network != network {
+		return nil, fmt.Errorf("部署记录 %s 属于网络 %s，而不是 %s", path, deployment.Network, network)
+	}
+
+	addresses := map[string]string{
+		"miniAMM": deployment.Contracts.MiniAMM,
+		"tokenA":  deployment.Contracts.TokenA,
+		"tokenB":  deployment.Contracts.TokenB,
+	}
+	for name, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("部署记录 %s 中 %s 地址无效: %q", path, name, address)
+		}
+	}
+
+	return &deployment, nil
+}
@@ -0,0 +1,164 @@
+package util
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"go.opentelemetry.io/otel/attribute"
+
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/tracing"
+)
+
+// QuorumEnabled 是否对关键读取（储备、手续费、bot()）启用多节点一致性校验
+func (r *RPCClient) QuorumEnabled() bool {
+	return r.config.RPCQuorum > 1
+}
+
+// QuorumCall 在同一区块高度向所有可用节点发起 eth_call，至少 RPCQuorum 个节点返回相同结果才返回该结果。
+// 区块高度取各节点最新区块的最小值，保证参与的节点都已同步到该区块；
+// 与多数结果不一致的节点记为分歧，当前节点出现分歧时切换到其他节点。
+func (r *RPCClient) QuorumCall(ctx context.Context, msg ethereum.CallMsg) (output []byte, err error) {
+	quorum := r.config.RPCQuorum
+	ctx, span := tracing.Start(ctx, "rpc.quorum_call",
+		attribute.String("chain", r.config.Name),
+		attribute.Int("rpc.quorum", quorum),
+	)
+	defer func() {
+		result := metrics.QuorumAgreed
+		if err != nil {
+			result = metrics.QuorumFailed
+		}
+		metrics.RPCQuorumReads.WithLabelValues(r.config.Name, result).Inc()
+		tracing.End(span, err)
+	}()
+
+	endpoints := r.usableEndpoints()
+	if len(endpoints) < quorum {
+		return nil, fmt.Errorf("可用 RPC 节点 %d 个，少于法定数量 %d", len(endpoints), quorum)
+	}
+
+	// 1. 各节点最新区块，取最小值
+	heads := make([]uint64, len(endpoints))
+	headErrs := r.fanOut(ctx, endpoints, "eth_blockNumber", func(ctx context.Context, i int, endpoint *rpcEndpoint) error {
+		head, err := endpoint.client.BlockNumber(ctx)
+		heads[i] = head
+		return err
+	})
+	var block uint64
+	live := []*rpcEndpoint{}
+	for i, endpoint := range endpoints {
+		if headErrs[i] != nil {
+			continue
+		}
+		if len(live) == 0 || heads[i] < block {
+			block = heads[i]
+		}
+		live = append(live, endpoint)
+	}
+	if len(live) < quorum {
+		return nil, fmt.Errorf("仅 %d 个 RPC 节点返回区块高度，少于法定数量 %d", len(live), quorum)
+	}
+	span.SetAttributes(attribute.Int64("rpc.block_number", int64(block)))
+
+	// 2. 在同一区块执行 eth_call
+	outputs := make([][]byte, len(live))
+	blockNumber := new(big.Int).SetUint64(block)
+	callErrs := r.fanOut(ctx, live, "eth_call", func(ctx context.Context, i int, endpoint *rpcEndpoint) error {
+		out, err := endpoint.client.CallContract(ctx, msg, blockNumber)
+		outputs[i] = out
+		return err
+	})
+
+	// 3. 按结果分组，取多数结果
+	groups := map[string][]int{}
+	majority := ""
+	for i := range live {
+		if callErrs[i] != nil {
+			continue
+		}
+		key := hex.EncodeToString(outputs[i])
+		groups[key] = append(groups[key], i)
+		if len(groups[key]) > len(groups[majority]) {
+			majority = key
+		}
+	}
+	agreed := groups[majority]
+	span.SetAttributes(attribute.Int("rpc.agreed", len(agreed)), attribute.Int("rpc.responders", len(live)))
+
+	for key, members := range groups {
+		if key == majority {
+			continue
+		}
+		if len(members) >= quorum {
+			// 两组结果都达到法定数量，无法判断哪一方正确
+			return nil, fmt.Errorf("区块 %d 上 RPC 节点返回了多组达到法定数量的不同结果", block)
+		}
+		for _, i := range members {
+			r.flagDivergence(live[i], block)
+		}
+	}
+
+	if len(agreed) < quorum {
+		return nil, fmt.Errorf("区块 %d 上仅 %d/%d 个 RPC 节点结果一致，未达到法定数量 %d", block, len(agreed), len(live), quorum)
+	}
+	return outputs[agreed[0]], nil
+}
+
+// usableEndpoints 当前可用的节点快照
+func (r *RPCClient) usableEndpoints() []*rpcEndpoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	endpoints := []*rpcEndpoint{}
+	for _, endpoint := range r.endpoints {
+		if endpoint.usable() {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// fanOut 并发地对每个节点执行 call，记录各节点的 RPC 指标并返回每个节点的错误
+func (r *RPCClient) fanOut(ctx context.Context, endpoints []*rpcEndpoint, method string, call func(context.Context, int, *rpcEndpoint) error) []error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	errs := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint *rpcEndpoint) {
+			defer wg.Done()
+			start := time.Now()
+			errs[i] = call(ctx, i, endpoint)
+			metrics.ObserveRPC(r.config.Name, endpoint.label, method, start, errs[i])
+			r.recordResult(endpoint, method, errs[i])
+		}(i, endpoint)
+	}
+	wg.Wait()
+	return errs
+}
+
+// flagDivergence 记录节点返回了与多数节点不一致的结果
+func (r *RPCClient) flagDivergence(endpoint *rpcEndpoint, block uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	endpoint.divergences++
+	endpoint.lastError = fmt.Sprintf("区块 %d 的 eth_call 结果与多数节点不一致", block)
+	metrics.RPCDivergences.WithLabelValues(r.config.Name, endpoint.label).Inc()
+	r.logger.Warnf("RPC 节点 %s 在区块 %d 的读取结果与多数节点不一致", endpoint.label, block)
+
+	if endpoint == r.endpoints[r.current] {
+		// 下一次健康检查通过后重新视为可用
+		endpoint.healthy = false
+		metrics.RPCEndpointUp.WithLabelValues(r.config.Name, endpoint.label).Set(0)
+		r.failoverLocked("读取结果与多数节点不一致")
+	}
+}
@@ -0,0 +1,204 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"mini-amm-bot/internal/logging"
+)
+
+// fakeNode 只实现 eth_blockNumber 和 eth_call 的 JSON-RPC 节点
+type fakeNode struct {
+	head    uint64
+	output  string // eth_call 返回的十六进制数据
+	headErr bool
+	callErr bool
+
+	mu         sync.Mutex
+	callBlocks []string // 收到的 eth_call 区块参数
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	failed := false
+	switch req.Method {
+	case "eth_blockNumber":
+		result, failed = hexutil.Uint64(n.head), n.headErr
+	case "eth_call":
+		var block string
+		if len(req.Params) > 1 {
+			json.Unmarshal(req.Params[1], &block)
+		}
+		n.mu.Lock()
+		n.callBlocks = append(n.callBlocks, block)
+		n.mu.Unlock()
+		result, failed = n.output, n.callErr
+	default:
+		http.Error(w, "unsupported method "+req.Method, http.StatusBadRequest)
+		return
+	}
+	if failed {
+		// 节点故障而不是 JSON-RPC 业务错误
+		http.Error(w, "node unavailable", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+// newQuorumClient 为每个节点启动一个 HTTP 服务，第一个节点为当前节点
+func newQuorumClient(t *testing.T, quorum int, nodes []*fakeNode) *RPCClient {
+	t.Helper()
+	r := &RPCClient{
+		config:  &ChainConfig{Name: "test", RPCQuorum: quorum, RPCFailoverThreshold: 100},
+		current: 0,
+		logger:  logging.Component("rpc"),
+	}
+	for i, node := range nodes {
+		server := httptest.NewServer(node)
+		t.Cleanup(server.Close)
+		client, err := ethclient.Dial(server.URL)
+		if err != nil {
+			t.Fatalf("连接测试节点失败: %v", err)
+		}
+		t.Cleanup(client.Close)
+		r.endpoints = append(r.endpoints, &rpcEndpoint{
+			url:         server.URL,
+			label:       fmt.Sprintf("node%d", i),
+			client:      client,
+			healthy:     true,
+			latency:     time.Duration(i+1) * time.Millisecond,
+			blockNumber: node.head,
+		})
+	}
+	return r
+}
+
+func TestQuorumCall(t *testing.T) {
+	tests := []struct {
+		name     string
+		quorum   int
+		nodes    []*fakeNode
+		want     string   // 期望的结果，为空表示期望返回错误
+		diverged []string // 被记为分歧的节点
+		current  string   // 调用后的当前节点
+	}{
+		{
+			name:    "全部一致",
+			quorum:  2,
+			nodes:   []*fakeNode{{head: 10, output: "0x01"}, {head: 10, output: "0x01"}, {head: 10, output: "0x01"}},
+			want:    "0x01",
+			current: "node0",
+		},
+		{
+			name:     "少数节点分歧",
+			quorum:   2,
+			nodes:    []*fakeNode{{head: 10, output: "0x01"}, {head: 10, output: "0x01"}, {head: 10, output: "0x02"}},
+			want:     "0x01",
+			diverged: []string{"node2"},
+			current:  "node0",
+		},
+		{
+			name:     "当前节点分歧时切换",
+			quorum:   2,
+			nodes:    []*fakeNode{{head: 10, output: "0x02"}, {head: 10, output: "0x01"}, {head: 10, output: "0x01"}},
+			want:     "0x01",
+			diverged: []string{"node0"},
+			current:  "node1",
+		},
+		{
+			name:    "出错的节点不计为分歧",
+			quorum:  2,
+			nodes:   []*fakeNode{{head: 10, output: "0x01"}, {head: 10, callErr: true}, {head: 10, output: "0x01"}},
+			want:    "0x01",
+			current: "node0",
+		},
+		{
+			name:     "一致数量未达到法定数量",
+			quorum:   3,
+			nodes:    []*fakeNode{{head: 10, output: "0x01"}, {head: 10, output: "0x01"}, {head: 10, output: "0x02"}},
+			diverged: []string{"node2"},
+			current:  "node0",
+		},
+		{
+			name:    "两组结果都达到法定数量",
+			quorum:  2,
+			nodes:   []*fakeNode{{head: 10, output: "0x01"}, {head: 10, output: "0x01"}, {head: 10, output: "0x02"}, {head: 10, output: "0x02"}},
+			current: "node0",
+		},
+		{
+			name:    "返回区块高度的节点不足",
+			quorum:  2,
+			nodes:   []*fakeNode{{head: 10, output: "0x01"}, {head: 10, headErr: true}},
+			current: "node0",
+		},
+		{
+			name:    "可用节点少于法定数量",
+			quorum:  3,
+			nodes:   []*fakeNode{{head: 10, output: "0x01"}, {head: 10, output: "0x01"}},
+			current: "node0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newQuorumClient(t, tt.quorum, tt.nodes)
+			output, err := r.QuorumCall(context.Background(), ethereum.CallMsg{To: &common.Address{}})
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("QuorumCall = %s, 应返回错误", hexutil.Encode(output))
+				}
+			} else if err != nil {
+				t.Fatalf("QuorumCall: %v", err)
+			} else if got := hexutil.Encode(output); got != tt.want {
+				t.Errorf("QuorumCall = %s, want %s", got, tt.want)
+			}
+
+			diverged := []string{}
+			for _, endpoint := range r.endpoints {
+				if endpoint.divergences > 0 {
+					diverged = append(diverged, endpoint.label)
+				}
+			}
+			if fmt.Sprint(diverged) != fmt.Sprint(tt.diverged) {
+				t.Errorf("分歧节点 %v, want %v", diverged, tt.diverged)
+			}
+			if got := r.endpoints[r.current].label; got != tt.current {
+				t.Errorf("当前节点 %s, want %s", got, tt.current)
+			}
+		})
+	}
+}
+
+func TestQuorumCallUsesLowestHead(t *testing.T) {
+	nodes := []*fakeNode{{head: 12, output: "0x01"}, {head: 10, output: "0x01"}, {head: 11, output: "0x01"}}
+	r := newQuorumClient(t, 2, nodes)
+	if _, err := r.QuorumCall(context.Background(), ethereum.CallMsg{To: &common.Address{}}); err != nil {
+		t.Fatalf("QuorumCall: %v", err)
+	}
+	for i, node := range nodes {
+		if len(node.callBlocks) != 1 || node.callBlocks[0] != "0xa" {
+			t.Errorf("node%d 的 eth_call 区块参数 %v, want [0xa]", i, node.callBlocks)
+		}
+	}
+}
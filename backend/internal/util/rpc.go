@@ -2,87 +2,404 @@ package util
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/metrics"
+	"mini-amm-bot/internal/tracing"
 )
 
+// rpcEndpoint 节点池中的单个 RPC 节点，状态字段由 RPCClient.mu 保护
+type rpcEndpoint struct {
+	url    string
+	label  string            // 只含 host，用于日志和指标
+	client *ethclient.Client // 为 nil 表示尚未连接成功
+
+	healthy     bool
+	lagging     bool // 区块高度落后最高节点超过 RPCMaxBlockLag
+	latency     time.Duration
+	blockNumber uint64
+	failures    int // 连续失败次数
+	divergences int // 多节点读取中与多数结果不一致的次数
+	lastError   string
+	checkedAt   time.Time
+}
+
+// usable 节点已连接、最近一次检查正常且没有明显落后
+func (e *rpcEndpoint) usable() bool {
+	return e.client != nil && e.healthy && !e.lagging
+}
+
+// blockLagPenalty 节点评分中每落后一个区块折算的延迟
+const blockLagPenalty = 500 * time.Millisecond
+
+// score 节点得分，越小越好：延迟加上落后 highest 的区块数按 blockLagPenalty 折算的延迟
+func (e *rpcEndpoint) score(highest uint64) time.Duration {
+	score := e.latency
+	if highest > e.blockNumber {
+		score += time.Duration(highest-e.blockNumber) * blockLagPenalty
+	}
+	return score
+}
+
+// EndpointStatus 节点状态快照，用于就绪检查和 API 展示
+type EndpointStatus struct {
+	Endpoint    string    `json:"endpoint"`
+	Current     bool      `json:"current"`
+	Healthy     bool      `json:"healthy"`
+	Lagging     bool      `json:"lagging"`
+	BlockNumber uint64    `json:"blockNumber"`
+	LatencyMs   int64     `json:"latencyMs"`
+	Failures    int       `json:"failures"`
+	Divergences int       `json:"divergences"`
+	LastError   string    `json:"lastError,omitempty"`
+	CheckedAt   time.Time `json:"checkedAt"`
+}
+
+// RPCClient 管理一条链的 RPC 节点池：定期检查每个节点的延迟和区块高度，
+// 当前节点连续出错或落后时自动切换到得分最高的节点。
+// 调用方每次通过 GetClient 取当前客户端，切换对 MiniAMMContract、TransactionService 透明。
 type RPCClient struct {
-	client          *ethclient.Client
-	config          *Config
-	currentEndpoint string
-	fallbackIndex   int
+	config    *ChainConfig
+	endpoints []*rpcEndpoint
+	mu        sync.RWMutex
+	current   int
+	logger    *log.Entry
+
+	onFailover func(from, to, reason string)
 }
 
-func NewRPCClient(config *Config) (*RPCClient, error) {
-	client, err := ethclient.Dial(config.RPCEndpoint)
-	if err != nil {
-		return nil, err
+// NewRPCClient 连接主节点和所有备用节点，至少一个节点连接成功即可
+func NewRPCClient(config *ChainConfig) (*RPCClient, error) {
+	r := &RPCClient{
+		config:  config,
+		current: -1,
+		logger:  logging.Component("rpc").WithField("chain", config.Name),
+	}
+
+	urls := append([]string{config.RPCEndpoint}, config.FallbackRPCEndpoints...)
+	for i, endpointURL := range urls {
+		endpoint := &rpcEndpoint{url: endpointURL, label: endpointLabel(endpointURL)}
+		client, err := ethclient.Dial(endpointURL)
+		if err != nil {
+			r.logger.Warnf("连接 RPC 节点 %s 失败: %v", endpoint.label, err)
+			endpoint.lastError = err.Error()
+		} else {
+			// 尚未做健康检查，先视为可用
+			endpoint.client = client
+			endpoint.healthy = true
+			if r.current < 0 {
+				r.current = i
+			}
+		}
+		r.endpoints = append(r.endpoints, endpoint)
+	}
+
+	if r.current < 0 {
+		return nil, fmt.Errorf("链 %s 的 %d 个 RPC 节点均连接失败", config.Name, len(urls))
 	}
+	return r, nil
+}
+
+// OnFailover 设置节点切换后的回调，参数为切换前后节点的 host 和原因；
+// 回调在新的 goroutine 中执行，可以安全地再调用 RPCClient
+func (r *RPCClient) OnFailover(fn func(from, to, reason string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onFailover = fn
+}
 
-	return &RPCClient{
-		client:          client,
-		config:          config,
-		currentEndpoint: config.RPCEndpoint,
-		fallbackIndex:   -1,
-	}, nil
+// Chain 返回该客户端连接的链配置
+func (r *RPCClient) Chain() *ChainConfig {
+	return r.config
 }
 
+// GetClient 返回当前节点的客户端，节点切换后返回新的客户端；需要记录调用结果时使用 Conn
 func (r *RPCClient) GetClient() *ethclient.Client {
-	return r.client
+	return r.Conn().Client
 }
 
-func (r *RPCClient) SwitchToFallback() error {
-	if len(r.config.FallbackRPCEndpoints) == 0 {
-		return nil
-	}
+// Conn 一次 RPC 调用使用的节点。调用前通过 RPCClient.Conn 取得，调用结束后把同一个 Conn 传给 Observe，
+// 调用期间发生切换时耗时、错误和失败计数仍记在实际处理请求的节点上
+type Conn struct {
+	*ethclient.Client
+	endpoint *rpcEndpoint
+}
+
+// Conn 返回当前节点
+func (r *RPCClient) Conn() Conn {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	endpoint := r.endpoints[r.current]
+	return Conn{Client: endpoint.client, endpoint: endpoint}
+}
 
-	r.fallbackIndex++
-	if r.fallbackIndex >= len(r.config.FallbackRPCEndpoints) {
-		r.fallbackIndex = 0
+// CurrentEndpoint 当前节点的 host
+func (r *RPCClient) CurrentEndpoint() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.endpoints[r.current].label
+}
+
+// Endpoints 返回所有节点的状态
+func (r *RPCClient) Endpoints() []EndpointStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]EndpointStatus, 0, len(r.endpoints))
+	for i, endpoint := range r.endpoints {
+		statuses = append(statuses, EndpointStatus{
+			Endpoint:    endpoint.label,
+			Current:     i == r.current,
+			Healthy:     endpoint.healthy,
+			Lagging:     endpoint.lagging,
+			BlockNumber: endpoint.blockNumber,
+			LatencyMs:   endpoint.latency.Milliseconds(),
+			Failures:    endpoint.failures,
+			Divergences: endpoint.divergences,
+			LastError:   endpoint.lastError,
+			CheckedAt:   endpoint.checkedAt,
+		})
 	}
+	return statuses
+}
 
-	fallbackEndpoint := r.config.FallbackRPCEndpoints[r.fallbackIndex]
-	log.Warnf("切换到备用 RPC 节点: %s", fallbackEndpoint)
+// Start 按 RPCHealthInterval 定期检查所有节点，直到 ctx 取消
+func (r *RPCClient) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.RPCHealthInterval)
+	defer ticker.Stop()
 
-	client, err := ethclient.Dial(fallbackEndpoint)
-	if err != nil {
-		log.Errorf("连接备用 RPC 节点失败: %v", err)
-		return err
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.checkEndpoints(ctx)
+		}
 	}
+}
 
-	if r.client != nil {
-		r.client.Close()
+// checkEndpoints 并发检查所有节点的延迟和区块高度，当前节点不可用时切换
+func (r *RPCClient) checkEndpoints(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, endpoint := range r.endpoints {
+		wg.Add(1)
+		go func(endpoint *rpcEndpoint) {
+			defer wg.Done()
+			r.checkEndpoint(ctx, endpoint)
+		}(endpoint)
 	}
+	wg.Wait()
 
-	r.client = client
-	r.currentEndpoint = fallbackEndpoint
-	return nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var highest uint64
+	for _, endpoint := range r.endpoints {
+		if endpoint.healthy && endpoint.blockNumber > highest {
+			highest = endpoint.blockNumber
+		}
+	}
+	for _, endpoint := range r.endpoints {
+		endpoint.lagging = endpoint.healthy && highest-endpoint.blockNumber > r.config.RPCMaxBlockLag
+		metrics.RPCEndpointUp.WithLabelValues(r.config.Name, endpoint.label).Set(metrics.BoolToFloat(endpoint.usable()))
+	}
+
+	if current := r.endpoints[r.current]; !current.usable() {
+		reason := "健康检查失败"
+		if current.lagging {
+			reason = fmt.Sprintf("区块高度 %d 落后最高节点 %d", current.blockNumber, highest)
+		}
+		r.failoverLocked(reason)
+	}
 }
 
-func (r *RPCClient) CheckConnection() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (r *RPCClient) checkEndpoint(ctx context.Context, endpoint *rpcEndpoint) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := r.client.BlockNumber(ctx)
+	r.mu.RLock()
+	client := endpoint.client
+	r.mu.RUnlock()
+
+	var err error
+	if client == nil {
+		client, err = ethclient.DialContext(ctx, endpoint.url)
+	}
+
+	var blockNumber uint64
+	start := time.Now()
+	if err == nil {
+		blockNumber, err = client.BlockNumber(ctx)
+		metrics.ObserveRPC(r.config.Name, endpoint.label, "eth_blockNumber", start, err)
+	}
+	latency := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if endpoint.client == nil && client != nil {
+		endpoint.client = client
+	}
+	endpoint.checkedAt = time.Now()
 	if err != nil {
-		log.Errorf("RPC 连接检查失败: %v", err)
-		return err
+		if endpoint.healthy {
+			r.logger.Warnf("RPC 节点 %s 健康检查失败: %v", endpoint.label, err)
+		}
+		endpoint.healthy = false
+		endpoint.lastError = err.Error()
+		return
+	}
+	if !endpoint.healthy {
+		r.logger.Infof("RPC 节点 %s 已恢复 (区块 %d, 延迟 %s)", endpoint.label, blockNumber, latency.Round(time.Millisecond))
+	}
+	endpoint.healthy = true
+	endpoint.failures = 0
+	endpoint.latency = latency
+	endpoint.blockNumber = blockNumber
+	endpoint.lastError = ""
+	metrics.RPCEndpointBlock.WithLabelValues(r.config.Name, endpoint.label).Set(float64(blockNumber))
+}
+
+// failoverLocked 切换到得分最高的其他可用节点，调用方需持有 mu 写锁。
+// 得分按延迟和区块高度计算（见 rpcEndpoint.score），得分相同时按配置顺序（主节点优先）。
+func (r *RPCClient) failoverLocked(reason string) {
+	var highest uint64
+	for _, endpoint := range r.endpoints {
+		if endpoint.usable() && endpoint.blockNumber > highest {
+			highest = endpoint.blockNumber
+		}
+	}
+
+	best := -1
+	var bestScore time.Duration
+	for i, endpoint := range r.endpoints {
+		if i == r.current || !endpoint.usable() {
+			continue
+		}
+		if score := endpoint.score(highest); best < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	from := r.endpoints[r.current]
+	if best < 0 {
+		r.logger.Errorf("RPC 节点 %s 不可用 (%s)，但没有其他可用节点", from.label, reason)
+		return
+	}
+
+	to := r.endpoints[best]
+	r.logger.Warnf("RPC 节点 %s 不可用 (%s)，切换到 %s", from.label, reason, to.label)
+	metrics.RPCFailovers.WithLabelValues(r.config.Name, from.label, to.label).Inc()
+	r.current = best
+	if r.onFailover != nil {
+		go r.onFailover(from.label, to.label, reason)
 	}
+}
+
+// Observe 记录一次 RPC 调用的耗时与错误，按链、处理请求的节点（conn）和方法区分；
+// ctx 中有进行中的 trace 时补记一个 rpc span。
+// 当前节点连续 RPCFailoverThreshold 次出现节点错误时自动切换。
+func (r *RPCClient) Observe(ctx context.Context, conn Conn, method string, start time.Time, err error) {
+	endpoint := conn.endpoint
+	metrics.ObserveRPC(r.config.Name, endpoint.label, method, start, err)
+
+	_, span := tracing.StartAt(ctx, "rpc "+method, start,
+		attribute.String("rpc.method", method),
+		attribute.String("chain", r.config.Name),
+		attribute.String("rpc.endpoint", endpoint.label),
+	)
+	tracing.End(span, err)
+
+	r.recordResult(endpoint, method, err)
+}
 
+func (r *RPCClient) recordResult(endpoint *rpcEndpoint, method string, err error) {
+	if err != nil && !isEndpointError(err) {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		endpoint.failures = 0
+		return
+	}
+
+	endpoint.failures++
+	endpoint.lastError = err.Error()
+	// 调用期间可能已经切换过节点，只对仍是当前节点的失败做切换
+	if endpoint != r.endpoints[r.current] || endpoint.failures < r.config.RPCFailoverThreshold {
+		return
+	}
+	endpoint.healthy = false
+	metrics.RPCEndpointUp.WithLabelValues(r.config.Name, endpoint.label).Set(0)
+	r.failoverLocked(fmt.Sprintf("%s 连续失败 %d 次: %v", method, endpoint.failures, err))
+}
+
+// isEndpointError 判断错误是否由节点本身引起（网络错误、超时、HTTP 错误），
+// 合约 revert、nonce 过低等 JSON-RPC 返回的业务错误不计入
+func isEndpointError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// endpointLabel 只保留节点 URL 的 host 作为指标标签，避免把路径中的 API key 暴露到指标里
+func endpointLabel(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "unknown"
+	}
+	return u.Host
+}
+
+// CheckConnection 启动时检查所有节点，当前节点不可用时切换，没有可用节点时返回错误
+func (r *RPCClient) CheckConnection() error {
+	r.checkEndpoints(context.Background())
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	current := r.endpoints[r.current]
+	if !current.usable() {
+		r.logger.Errorf("RPC 连接检查失败: %s", current.lastError)
+		return fmt.Errorf("没有可用的 RPC 节点: %s", current.lastError)
+	}
 	return nil
 }
 
-func (r *RPCClient) GetBlockNumber() (uint64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *RPCClient) GetBlockNumber(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	return r.client.BlockNumber(ctx)
+	start := time.Now()
+	conn := r.Conn()
+	blockNumber, err := conn.BlockNumber(ctx)
+	r.Observe(ctx, conn, "eth_blockNumber", start, err)
+	return blockNumber, err
 }
 
 func (r *RPCClient) Close() {
-	if r.client != nil {
-		r.client.Close()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, endpoint := range r.endpoints {
+		if endpoint.client != nil {
+			endpoint.client.Close()
+		}
 	}
 }
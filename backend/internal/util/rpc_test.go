@@ -0,0 +1,188 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"mini-amm-bot/internal/logging"
+)
+
+// testEndpoint 测试用的节点状态，connected 时填入 client
+type testEndpoint struct {
+	label       string
+	connected   bool
+	healthy     bool
+	lagging     bool
+	latency     time.Duration
+	blockNumber uint64
+}
+
+func newTestRPCClient(current int, endpoints ...testEndpoint) *RPCClient {
+	r := &RPCClient{
+		config:  &ChainConfig{Name: "test", RPCFailoverThreshold: 2},
+		current: current,
+		logger:  logging.Component("rpc"),
+	}
+	for _, e := range endpoints {
+		endpoint := &rpcEndpoint{
+			url:         "http://" + e.label,
+			label:       e.label,
+			healthy:     e.healthy,
+			lagging:     e.lagging,
+			latency:     e.latency,
+			blockNumber: e.blockNumber,
+		}
+		if e.connected {
+			endpoint.client = ethclient.NewClient(nil)
+		}
+		r.endpoints = append(r.endpoints, endpoint)
+	}
+	return r
+}
+
+func up(label string, latency time.Duration, blockNumber uint64) testEndpoint {
+	return testEndpoint{label: label, connected: true, healthy: true, latency: latency, blockNumber: blockNumber}
+}
+
+func TestFailoverLocked(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		current   int
+		endpoints []testEndpoint
+		want      string
+	}{
+		{
+			name:      "选延迟最低的节点",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), up("b", 300*ms, 100), up("c", 50*ms, 100)},
+			want:      "c",
+		},
+		{
+			name:      "落后的区块按 blockLagPenalty 折算",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), up("b", 10*ms, 98), up("c", 900*ms, 100)},
+			want:      "c",
+		},
+		{
+			name:      "落后一个区块优于多 600ms 延迟",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), up("b", 10*ms, 99), up("c", 610*ms, 100)},
+			want:      "b",
+		},
+		{
+			name:      "最高区块只看可用节点",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), {label: "b", connected: true, blockNumber: 200}, up("c", 10*ms, 90), up("d", 100*ms, 99)},
+			want:      "d",
+		},
+		{
+			name:      "得分相同时按配置顺序",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), up("b", 20*ms, 100), up("c", 20*ms, 100)},
+			want:      "b",
+		},
+		{
+			name:      "跳过未连接、不健康和落后的节点",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), {label: "b", healthy: true, blockNumber: 100}, {label: "c", connected: true, blockNumber: 100}, {label: "d", connected: true, healthy: true, lagging: true, blockNumber: 100}, up("e", 900*ms, 100)},
+			want:      "e",
+		},
+		{
+			name:      "不切回当前节点",
+			current:   1,
+			endpoints: []testEndpoint{up("a", 100*ms, 100), up("b", 1*ms, 100)},
+			want:      "a",
+		},
+		{
+			name:      "没有其他可用节点时保持不变",
+			endpoints: []testEndpoint{up("a", 10*ms, 100), {label: "b", connected: true, blockNumber: 100}},
+			want:      "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRPCClient(tt.current, tt.endpoints...)
+			r.failoverLocked("test")
+			if got := r.endpoints[r.current].label; got != tt.want {
+				t.Errorf("切换到 %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// testRPCError JSON-RPC 返回的业务错误
+type testRPCError struct{ code int }
+
+func (e testRPCError) Error() string  { return fmt.Sprintf("rpc error %d", e.code) }
+func (e testRPCError) ErrorCode() int { return e.code }
+
+func TestIsEndpointError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"网络错误", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"超时", context.DeadlineExceeded, true},
+		{"HTTP 错误", errors.New("502 Bad Gateway"), true},
+		{"取消", context.Canceled, false},
+		{"包装的取消", fmt.Errorf("获取区块失败: %w", context.Canceled), false},
+		{"未找到", ethereum.NotFound, false},
+		{"JSON-RPC 错误", testRPCError{code: -32000}, false},
+		{"包装的 JSON-RPC 错误", fmt.Errorf("发送交易失败: %w", testRPCError{code: 3}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEndpointError(tt.err); got != tt.want {
+				t.Errorf("isEndpointError(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordResultFailover(t *testing.T) {
+	endpointErr := errors.New("connection reset")
+	tests := []struct {
+		name    string
+		results []error // 按顺序记录到当前节点
+		want    string
+	}{
+		{"达到阈值后切换", []error{endpointErr, endpointErr}, "b"},
+		{"未达到阈值", []error{endpointErr}, "a"},
+		{"成功清零连续失败", []error{endpointErr, nil, endpointErr}, "a"},
+		{"业务错误不计入", []error{endpointErr, testRPCError{code: -32000}}, "a"},
+		{"业务错误不清零", []error{endpointErr, testRPCError{code: -32000}, endpointErr}, "b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRPCClient(0, up("a", time.Millisecond, 100), up("b", time.Millisecond, 100))
+			current := r.endpoints[0]
+			for _, err := range tt.results {
+				r.recordResult(current, "eth_call", err)
+			}
+			if got := r.endpoints[r.current].label; got != tt.want {
+				t.Errorf("当前节点 %s, want %s", got, tt.want)
+			}
+			if tt.want == "b" && current.healthy {
+				t.Error("切换走的节点应标记为不健康")
+			}
+		})
+	}
+}
+
+func TestRecordResultIgnoresFormerEndpoint(t *testing.T) {
+	r := newTestRPCClient(1, up("a", time.Millisecond, 100), up("b", time.Millisecond, 100))
+	former := r.endpoints[0]
+	// 调用开始后已经切换到 b，a 上的失败只计数不再切换
+	for i := 0; i < 3; i++ {
+		r.recordResult(former, "eth_call", errors.New("connection reset"))
+	}
+	if got := r.endpoints[r.current].label; got != "b" {
+		t.Errorf("当前节点 %s, want b", got)
+	}
+	if former.failures != 3 {
+		t.Errorf("failures = %d, want 3", former.failures)
+	}
+}
@@ -2,17 +2,26 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"mini-amm-bot/internal/alerting"
 	"mini-amm-bot/internal/api"
 	"mini-amm-bot/internal/db"
+	"mini-amm-bot/internal/events"
+	"mini-amm-bot/internal/health"
+	"mini-amm-bot/internal/logging"
+	"mini-amm-bot/internal/scheduler"
 	services "mini-amm-bot/internal/services"
+	"mini-amm-bot/internal/subscribers"
+	"mini-amm-bot/internal/tracing"
 	util "mini-amm-bot/internal/util"
 )
 
@@ -22,6 +31,17 @@ func main() {
 	})
 	log.SetLevel(log.InfoLevel)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		}
+	}
+
 	log.Info("🚀 Mini-AMM Keeper Bot 启动中...")
 
 	config, err := util.LoadConfig()
@@ -29,72 +49,170 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
-	log.Infof("配置加载成功:")
-	log.Infof("  RPC: %s", config.RPCEndpoint)
-	log.Infof("  合约地址: %s", config.ContractAddress)
-	log.Infof("  Chain ID: %d", config.ChainID)
-	log.Infof("  复投间隔: %s", config.CompoundInterval)
-	log.Infof("  再平衡间隔: %s", config.RebalanceInterval)
-	log.Infof("  再平衡阈值: %.2f%%", config.RebalanceThreshold*100)
-
-	// Initialize database
-	dbHost := os.Getenv("DB_HOST")
-	if dbHost == "" {
-		dbHost = "postgres"
-	}
-	dbConfig := db.Config{
-		Host:     dbHost,
-		Port:     5432,
-		User:     "graph-node",
-		Password: "let-me-in",
-		DBName:   "graph-node",
-	}
-
-	postgres, err := db.NewPostgresDB(dbConfig)
-	if err != nil {
-		log.Fatalf("连接数据库失败: %v", err)
+	if err := logging.Setup(config.LogFormat, config.LogLevel, config.LogLevels); err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
 	}
-	defer postgres.Close()
 
-	if err := postgres.InitSchema(); err != nil {
-		log.Fatalf("初始化数据库表失败: %v", err)
+	log.Infof("配置加载成功:")
+	for _, chain := range config.Chains {
+		log.Infof("  链 [%s]:", chain.Name)
+		log.Infof("    RPC: %s", chain.RPCEndpoint)
+		if len(chain.FallbackRPCEndpoints) > 0 {
+			log.Infof("    备用 RPC: %d 个", len(chain.FallbackRPCEndpoints))
+		}
+		log.Infof("    Chain ID: %d", chain.ChainID)
+		log.Infof("    确认数: %d (最长等待 %s)", chain.Confirmations, chain.ConfirmTimeout)
+	}
+	for _, pool := range config.Pools {
+		log.Infof("  池 [%s] (链 %s):", pool.Name, pool.Chain)
+		log.Infof("    合约地址: %s", pool.ContractAddress)
+		if pool.Network != "" {
+			log.Infof("    部署记录: %s (部署区块 %d)", pool.Network, pool.DeploymentBlock)
+		}
+		log.Infof("    复投调度: %s", scheduleLabel(pool.CompoundSchedule, pool.CompoundInterval))
+		log.Infof("    再平衡调度: %s", scheduleLabel(pool.RebalanceSchedule, pool.RebalanceInterval))
+		log.Infof("    再平衡阈值: %.2f%%", pool.RebalanceThreshold*100)
 	}
 
-	botActionRepo := db.NewBotActionRepository(postgres.GetDB())
+	// 链路追踪：配置了 OTEL_EXPORTER_OTLP_ENDPOINT 时通过 OTLP 导出，否则不记录
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		log.Fatalf("初始化链路追踪失败: %v", err)
+	}
+	if tracing.Enabled() {
+		log.Info("✅ OpenTelemetry 链路追踪已开启")
+	}
 
-	rpcClient, err := util.NewRPCClient(config)
+	// 连接数据库并执行迁移，DB_DRIVER 选择 postgres（默认）、sqlite 或 memory
+	stores, err := db.Open(context.Background(), config.Database)
 	if err != nil {
-		log.Fatalf("连接 RPC 节点失败: %v", err)
+		log.Fatalf("初始化数据库失败: %v", err)
 	}
-	defer rpcClient.Close()
+	defer stores.Database.Close()
+	botActionRepo, database := stores.BotActions, stores.Database
+
+	// 进程内事件总线：服务只发布领域事件（检查开始/结束、跳过、交易发送/确认/失败、预言机过期、池状态），
+	// 保存记录与快照、更新指标由同步处理器完成，/api/stream 与 /api/ws 把事件转发给前端
+	bus := events.NewBus(events.DefaultHistorySize)
+	bus.Handle("recorder", subscribers.NewRecorder(botActionRepo, stores.PoolSnapshots, config, bus).Handle)
+	bus.Handle("metrics", subscribers.Metrics)
 
-	if err := rpcClient.CheckConnection(); err != nil {
-		log.Fatalf("RPC 连接检查失败: %v", err)
+	// 告警：配置了 webhook、Slack 或 SMTP 时根据总线事件和定期余额检查发送通知
+	alerts := alerting.NewManager(config, alerting.NewSinks(config.Alert))
+	if alerts.Enabled() {
+		bus.Handle("alerts", alerts.Handle)
+		log.Infof("✅ 告警通知已开启: %s", strings.Join(alerts.Sinks(), ", "))
 	}
-	log.Info("✅ RPC 连接成功")
 
-	txService, err := services.NewTransactionService(config, rpcClient)
-	if err != nil {
-		log.Fatalf("初始化交易服务失败: %v", err)
+	// 每条链一个 RPC 客户端和一个 TransactionService（独立的签名账户、gas 策略和 nonce 序列）
+	rpcClients := make(map[string]*util.RPCClient, len(config.Chains))
+	txServices := make(map[string]*services.TransactionService, len(config.Chains))
+	for _, chain := range config.Chains {
+		rpcClient, err := util.NewRPCClient(chain)
+		if err != nil {
+			log.Fatalf("连接 RPC 节点失败 [%s]: %v", chain.Name, err)
+		}
+		defer rpcClient.Close()
+
+		if err := rpcClient.CheckConnection(); err != nil {
+			log.Fatalf("RPC 连接检查失败 [%s]: %v", chain.Name, err)
+		}
+		log.Infof("✅ RPC 连接成功 [%s]", chain.Name)
+
+		txService, err := services.NewTransactionService(config, rpcClient)
+		if err != nil {
+			log.Fatalf("初始化交易服务失败 [%s]: %v", chain.Name, err)
+		}
+
+		log.Infof("Bot 账户地址 [%s]: %s", chain.Name, txService.GetFromAddress().Hex())
+
+		balance, err := txService.GetBalance()
+		if err != nil {
+			log.Warnf("获取账户余额失败 [%s]: %v", chain.Name, err)
+		} else {
+			log.Infof("账户余额 [%s]: %s ETH", chain.Name, formatEther(balance))
+		}
+
+		chainName := chain.Name
+		rpcClient.OnFailover(func(from, to, reason string) {
+			bus.Publish(context.Background(), events.Event{Chain: chainName, Data: events.RPCFailover{From: from, To: to, Reason: reason}})
+		})
+		if alerts.Enabled() {
+			alerts.WatchBalance(chain, txService)
+		}
+
+		rpcClients[chain.Name] = rpcClient
+		txServices[chain.Name] = txService
 	}
 
-	log.Infof("Bot 账户地址: %s", txService.GetFromAddress().Hex())
+	// 每个池一组复投/再平衡服务，同一条链上的池共享该链的 TransactionService（即同一条 nonce 序列），
+	// 所有任务注册到同一个调度器，由 /api/schedules 展示下次执行时间
+	sched := scheduler.New()
+	compoundServices := make([]*services.CompoundService, 0, len(config.Pools))
+	rebalanceServices := make([]*services.RebalanceService, 0, len(config.Pools))
+	for _, pool := range config.Pools {
+		rpcClient := rpcClients[pool.Chain]
+		txService := txServices[pool.Chain]
 
-	balance, err := txService.GetBalance()
-	if err != nil {
-		log.Warnf("获取账户余额失败: %v", err)
-	} else {
-		log.Infof("账户余额: %s ETH", formatEther(balance))
+		compoundService, err := services.NewCompoundService(config, pool, rpcClient, txService, botActionRepo, bus, sched)
+		if err != nil {
+			log.Fatalf("初始化复投服务失败 [%s]: %v", pool.Name, err)
+		}
+
+		rebalanceService, err := services.NewRebalanceServiceMarket(config, rpcClient, txService, compoundService, bus, sched)
+		if err != nil {
+			log.Fatalf("初始化再平衡服务失败 [%s]: %v", pool.Name, err)
+		}
+
+		compoundServices = append(compoundServices, compoundService)
+		rebalanceServices = append(rebalanceServices, rebalanceService)
 	}
 
-	compoundService, err := services.NewCompoundService(config, rpcClient, txService, botActionRepo)
-	if err != nil {
-		log.Fatalf("初始化复投服务失败: %v", err)
+	// 启动自检：strict 模式下任一检查失败拒绝启动，readonly 模式下受影响的池只读运行
+	preflight := services.RunPreflight(config, txServices, compoundServices)
+	for _, result := range preflight.Results {
+		switch result.Status {
+		case services.CheckFail:
+			log.Errorf("❌ 自检失败 [%s/%s] %s: %s", result.Chain, result.Pool, result.Name, result.Message)
+		case services.CheckWarn:
+			log.Warnf("⚠️  自检警告 [%s/%s] %s: %s", result.Chain, result.Pool, result.Name, result.Message)
+		default:
+			log.Debugf("自检通过 [%s/%s] %s: %s", result.Chain, result.Pool, result.Name, result.Message)
+		}
+	}
+	if !preflight.Passed() {
+		if config.PreflightMode != util.PreflightModeReadOnly {
+			log.Fatalf("启动自检失败 (%d 项)，拒绝启动；可设置 PREFLIGHT_MODE=readonly 以只读模式运行", len(preflight.Failures()))
+		}
+		for i, compoundService := range compoundServices {
+			pool := compoundService.Pool()
+			if preflight.PoolFailed(pool) {
+				compoundService.SetReadOnly(true)
+				rebalanceServices[i].SetReadOnly(true)
+				preflight.ReadOnlyPools = append(preflight.ReadOnlyPools, pool.Name)
+				log.Warnf("池 [%s] 自检未通过，以只读模式运行", pool.Name)
+			}
+		}
+	} else {
+		log.Info("✅ 启动自检通过")
 	}
 
-	rebalanceService, err := services.NewRebalanceServiceMarket(config, rpcClient, txService, compoundService, botActionRepo)
-	if err != nil {
-		log.Fatalf("初始化再平衡服务失败: %v", err)
+	// 就绪检查：数据库、每条链的 RPC 与签名账户余额、每个池的服务执行情况与价格新鲜度
+	readiness := health.NewChecker(5 * time.Second)
+	readiness.Register(health.Check{
+		Name: "database",
+		Run: func(ctx context.Context) health.Component {
+			if err := database.Ping(ctx); err != nil {
+				return health.Unhealthy(fmt.Sprintf("数据库连接失败: %v", err), nil)
+			}
+			return health.OK(nil)
+		},
+	})
+	for _, chain := range config.Chains {
+		readiness.Register(services.ChainReadinessChecks(txServices[chain.Name])...)
+	}
+	for i, compoundService := range compoundServices {
+		readiness.Register(services.PoolReadinessChecks(compoundService, rebalanceServices[i])...)
 	}
 
 	// Start API server
@@ -102,7 +220,7 @@ func main() {
 	if portStr := os.Getenv("API_PORT"); portStr != "" {
 		// Could parse port here if needed
 	}
-	apiServer := api.NewServer(apiPort, botActionRepo, config)
+	apiServer := api.NewServer(apiPort, botActionRepo, stores.PoolSnapshots, bus, config, preflight, readiness, sched, compoundServices, rebalanceServices)
 	go func() {
 		if err := apiServer.Start(); err != nil {
 			log.Errorf("API 服务器错误: %v", err)
@@ -112,8 +230,19 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go compoundService.Start(ctx)
-	go rebalanceService.Start(ctx)
+	for _, rpcClient := range rpcClients {
+		go rpcClient.Start(ctx)
+	}
+	if alerts.Enabled() {
+		go alerts.Run(ctx)
+	}
+
+	for _, compoundService := range compoundServices {
+		go compoundService.Start(ctx)
+	}
+	for _, rebalanceService := range rebalanceServices {
+		go rebalanceService.Start(ctx)
+	}
 
 	log.Info("✅ Keeper Bot 运行中...")
 	log.Info("按 Ctrl+C 停止")
@@ -131,10 +260,21 @@ func main() {
 	if err := apiServer.Shutdown(shutdownCtx); err != nil {
 		log.Errorf("API 服务器关闭错误: %v", err)
 	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		log.Errorf("导出剩余 trace 失败: %v", err)
+	}
 
 	log.Info("👋 Keeper Bot 已停止")
 }
 
+// scheduleLabel 启动日志中展示的调度规则，未配置时为固定间隔
+func scheduleLabel(schedule string, interval time.Duration) string {
+	if schedule == "" {
+		return interval.String()
+	}
+	return schedule
+}
+
 func formatEther(wei *big.Int) string {
 	if wei == nil {
 		return "0"
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"mini-amm-bot/internal/db"
+	util "mini-amm-bot/internal/util"
+)
+
+const migrateUsage = "用法: mini-amm-bot migrate [up | down [N] | status]"
+
+// runMigrate 处理 migrate 子命令：up 执行所有未执行的迁移，down 回滚最近 N 个（默认 1），status 列出各版本状态
+func runMigrate(args []string) {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	steps := 1
+	switch command {
+	case "up", "status":
+		if len(args) > 1 {
+			log.Fatal(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			log.Fatal(migrateUsage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				log.Fatalf("回滚数量必须是正整数: %s", args[1])
+			}
+			steps = n
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+
+	config, err := util.LoadDatabaseConfig()
+	if err != nil {
+		log.Fatalf("加载数据库配置失败: %v", err)
+	}
+
+	ctx := context.Background()
+	var sqlDB *sql.DB
+	var dialect db.Dialect
+	switch config.Driver {
+	case db.DriverPostgres, "":
+		postgres, err := db.NewPostgresDB(ctx, config)
+		if err != nil {
+			log.Fatalf("连接数据库失败: %v", err)
+		}
+		defer postgres.Close()
+		sqlDB, dialect = postgres.GetDB(), db.DialectPostgres
+	case db.DriverSQLite:
+		sqlite, err := db.NewSQLiteDB(config.SQLitePath)
+		if err != nil {
+			log.Fatalf("打开数据库失败: %v", err)
+		}
+		defer sqlite.Close()
+		sqlDB, dialect = sqlite.GetDB(), db.DialectSQLite
+	default:
+		log.Fatalf("DB_DRIVER=%s 不需要迁移", config.Driver)
+	}
+
+	migrator, err := db.NewMigrator(sqlDB, dialect)
+	if err != nil {
+		log.Fatalf("加载数据库迁移失败: %v", err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		log.Infof("✅ 执行了 %d 个迁移", applied)
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("回滚数据库迁移失败: %v", err)
+		}
+		log.Infof("✅ 回滚了 %d 个迁移", reverted)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("查询迁移状态失败: %v", err)
+		}
+		for _, status := range statuses {
+			applied := "未执行"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-28s  %s\n", status.Version, status.Name, applied)
+		}
+	}
+}
@@ -31,7 +31,8 @@ async function main() {
   const miniAMM = await MiniAMM.deploy(tokenAAddress, tokenBAddress);
   await miniAMM.waitForDeployment();
   const miniAMMAddress = await miniAMM.getAddress();
-  console.log("MiniAMM 部署到:", miniAMMAddress);
+  const miniAMMReceipt = await miniAMM.deploymentTransaction().wait();
+  console.log("MiniAMM 部署到:", miniAMMAddress, "(区块", miniAMMReceipt.blockNumber + ")");
 
   console.log("\n3. 初始化流动性...");
   const liquidityAmountA = hre.ethers.parseEther("10000");
@@ -63,6 +64,7 @@ async function main() {
       tokenB: tokenBAddress,
       miniAMM: miniAMMAddress
     },
+    deploymentBlock: miniAMMReceipt.blockNumber,
     timestamp: new Date().toISOString()
   };
 